@@ -0,0 +1,72 @@
+package ddb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"code.olapie.com/sugar/v2/xerror"
+)
+
+// CursorCodec converts page tokens into opaque cursors and back.
+// Cursors are encrypted and authenticated with AES-GCM, so clients can neither read nor tamper with the keys inside.
+type CursorCodec struct {
+	aead cipher.AEAD
+}
+
+// NewCursorCodec creates a CursorCodec whose AES key is derived from secret. It panics if secret is empty.
+func NewCursorCodec(secret []byte) *CursorCodec {
+	if len(secret) == 0 {
+		panic("empty cursor secret")
+	}
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &CursorCodec{aead: aead}
+}
+
+// Encode converts token returned by DynamoDB into a cursor. Empty token stays empty.
+func (c *CursorCodec) Encode(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("rand.Read: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(token), nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decode converts a cursor back to the token. It returns a bad request error if cursor has been tampered with.
+func (c *CursorCodec) Decode(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", xerror.BadRequest("invalid token")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	token, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", xerror.BadRequest("invalid token")
+	}
+	return string(token), nil
+}
+
+// WithCursorCodec makes QueryPage accept and return opaque cursors encoded by c instead of plain tokens
+func WithCursorCodec[E any, P PartitionKeyConstraint, S SortKeyConstraint](c *CursorCodec) TableOption[E, P, S] {
+	return func(t *Table[E, P, S]) {
+		t.cursorCodec = c
+	}
+}
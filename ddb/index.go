@@ -15,9 +15,10 @@ func NewIndex[E any, P PartitionKeyConstraint, S SortKeyConstraint](
 	tableName string,
 	indexName string,
 	pk *PrimaryKeyDefinition[P, S],
+	options ...TableOption[E, P, S],
 ) *Index[E, P, S] {
 	i := &Index[E, P, S]{
-		table: NewTable[E, P, S](db, tableName, pk),
+		table: NewTable[E, P, S](db, tableName, pk, options...),
 	}
 	i.table.indexName = &indexName
 	return i
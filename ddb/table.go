@@ -35,6 +35,7 @@ type Table[E any, P PartitionKeyConstraint, S SortKeyConstraint] struct {
 	pkDefinition   *PrimaryKeyDefinition[P, S]
 	columns        []string
 	consistentRead *bool
+	cursorCodec    *CursorCodec
 }
 
 func NewTable[E any, P PartitionKeyConstraint, S SortKeyConstraint](
@@ -246,6 +247,13 @@ func (t *Table[E, P, S]) QueryPage(ctx context.Context, partition P, sortKey *S,
 		op(input)
 	}
 
+	if startToken != "" && t.cursorCodec != nil {
+		startToken, err = t.cursorCodec.Decode(startToken)
+		if err != nil {
+			return nil, nextToken, err
+		}
+	}
+
 	if startToken != "" {
 		input.ExclusiveStartKey, err = t.pkDefinition.DecodeStringToValue(startToken)
 		if err != nil {
@@ -266,6 +274,13 @@ func (t *Table[E, P, S]) QueryPage(ctx context.Context, partition P, sortKey *S,
 	} else {
 		nextToken = t.pkDefinition.EncodeValueToString(output.LastEvaluatedKey)
 	}
+
+	if t.cursorCodec != nil {
+		nextToken, err = t.cursorCodec.Encode(nextToken)
+		if err != nil {
+			return nil, "", err
+		}
+	}
 	return items, nextToken, nil
 }
 
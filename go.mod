@@ -7,6 +7,7 @@ require (
 	code.olapie.com/router v1.0.4
 	code.olapie.com/sugar/v2 v2.0.2
 	code.olapie.com/sugar/v2/xcontact v0.1.1
+	github.com/andybalholm/brotli v1.0.4
 	github.com/aws/aws-lambda-go v1.35.0
 	github.com/aws/aws-sdk-go-v2 v1.17.3
 	github.com/aws/aws-sdk-go-v2/config v1.18.2
@@ -19,7 +19,11 @@ func Error(err error) *Response {
 	}
 
 	if er, ok := err.(*xerror.Error); ok {
-		return JSON(er.Code, er)
+		return JSON(httpErrorStatus(er.Code), er)
+	}
+
+	if be, ok := err.(*BindError); ok {
+		return bindErrorResponse(be)
 	}
 
 	var er xerror.Error
@@ -28,7 +32,15 @@ func Error(err error) *Response {
 		er.Code = http.StatusInternalServerError
 	}
 	er.Message = err.Error()
-	return JSON(er.Code, er)
+	return JSON(httpErrorStatus(er.Code), er)
+}
+
+// httpErrorStatus returns code as HTTP status if it's a client or server error, otherwise 500
+func httpErrorStatus(code int) int {
+	if code < http.StatusBadRequest || code > 599 {
+		return http.StatusInternalServerError
+	}
+	return code
 }
 
 func OK() *Response {
@@ -149,7 +161,15 @@ func BuildContext(ctx context.Context, request *Request) context.Context {
 	ctx = xcontext.WithAppID(ctx, appID)
 	ctx = xcontext.WithClientID(ctx, clientID)
 	ctx = xcontext.WithTraceID(ctx, traceID)
+	ctx = TraceIDKey.Set(ctx, traceID)
+	if request.RouteKey != "" {
+		ctx = RoutePatternKey.Set(ctx, request.RouteKey)
+	}
 	logger := log.FromContext(ctx).With(log.String("trace_id", traceID))
+	ctx = WithXRayTraceHeader(ctx, xhttp.GetHeader(request.Headers, KeyXRayTraceID))
+	if xrayTraceID := XRayTraceID(ctx); xrayTraceID != "" {
+		logger = logger.With(log.String("xray_trace_id", xrayTraceID))
+	}
 	ctx = log.BuildContext(ctx, logger)
 	return ctx
 }
@@ -0,0 +1,107 @@
+package lambdahttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+
+	"code.olapie.com/log"
+	"code.olapie.com/sugar/v2/xhttp"
+)
+
+const KeyAPIKey = "X-API-Key"
+
+// APIKeyStore looks up the identity owning an API key. It returns nil if the key is unknown or revoked.
+// Implementations backed by a database, e.g. DynamoDB, should store and query by HashAPIKey(key) rather than the key,
+// so that leaked tables don't leak usable keys and lookups don't leak timing of key comparison.
+type APIKeyStore interface {
+	LookupAPIKey(ctx context.Context, key string) (*Identity, error)
+}
+
+// HashAPIKey returns hex encoded sha256 of key
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// MemoryAPIKeyStore keeps key hashes in memory, e.g. for a handful of keys loaded from environment or secrets
+type MemoryAPIKeyStore struct {
+	mu   sync.RWMutex
+	keys map[[sha256.Size]byte]*Identity
+}
+
+var _ APIKeyStore = (*MemoryAPIKeyStore)(nil)
+
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{
+		keys: make(map[[sha256.Size]byte]*Identity),
+	}
+}
+
+func (s *MemoryAPIKeyStore) Add(key string, identity *Identity) {
+	s.mu.Lock()
+	s.keys[sha256.Sum256([]byte(key))] = identity
+	s.mu.Unlock()
+}
+
+func (s *MemoryAPIKeyStore) Remove(key string) {
+	s.mu.Lock()
+	delete(s.keys, sha256.Sum256([]byte(key)))
+	s.mu.Unlock()
+}
+
+// LookupAPIKey compares key's hash with all stored hashes in constant time
+func (s *MemoryAPIKeyStore) LookupAPIKey(ctx context.Context, key string) (*Identity, error) {
+	hash := sha256.Sum256([]byte(key))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var found *Identity
+	for h, identity := range s.keys {
+		if subtle.ConstantTimeCompare(h[:], hash[:]) == 1 {
+			found = identity
+		}
+	}
+	return found, nil
+}
+
+// VerifyAPIKey authenticates requests with an API key in header X-API-Key or Authorization (Bearer scheme or bare key),
+// and stores the owner in context with IdentityKey. Requests without a known key are rejected with 401.
+func VerifyAPIKey(store APIKeyStore) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		key := apiKeyOf(request)
+		if key == "" {
+			return errorStatus(http.StatusUnauthorized, "missing api key")
+		}
+		identity, err := store.LookupAPIKey(ctx, key)
+		if err != nil {
+			log.FromContext(ctx).Error("Cannot look up api key", log.Error(err))
+			return Error(err)
+		}
+		if identity == nil {
+			log.FromContext(ctx).Warn("Invalid api key",
+				log.String("source_ip", request.RequestContext.HTTP.SourceIP))
+			return errorStatus(http.StatusUnauthorized, "invalid api key")
+		}
+		logger := log.FromContext(ctx).With(log.String("identity", identity.ID))
+		ctx = log.BuildContext(IdentityKey.Set(ctx, identity), logger)
+		return Next(ctx, request)
+	}
+}
+
+func apiKeyOf(request *Request) string {
+	if key := xhttp.GetHeader(request.Headers, KeyAPIKey); key != "" {
+		return strings.TrimSpace(key)
+	}
+	auth := strings.TrimSpace(xhttp.GetHeader(request.Headers, "Authorization"))
+	if scheme, token, ok := strings.Cut(auth, " "); ok {
+		if !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(token)
+	}
+	return auth
+}
@@ -0,0 +1,270 @@
+package lambdahttp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// BindOptions are passed per call, so handlers of different routes can bind with different limits
+type BindOptions struct {
+	// DisallowUnknownFields rejects JSON objects with fields which don't exist in the target struct
+	DisallowUnknownFields bool
+
+	// MaxSize rejects bodies larger than MaxSize bytes with 413 if positive
+	MaxSize int
+
+	// MaxDepth rejects bodies whose objects and arrays are nested deeper than MaxDepth with 400 if positive
+	MaxDepth int
+
+	// MaxTokens rejects bodies with more than MaxTokens JSON tokens, i.e. delimiters, keys and values, with 400 if positive
+	MaxTokens int
+}
+
+// FieldError describes why a JSON field is invalid. Field is the path from root, e.g. items[0].name
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// BindError is returned by Bind and BindValid. Error converts it into a 400 (or Status) response enumerating the field errors.
+type BindError struct {
+	Message string        `json:"message"`
+	Fields  []*FieldError `json:"fields,omitempty"`
+
+	// Status of the response, 400 if zero
+	Status int `json:"-"`
+}
+
+func (e *BindError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Reason
+	}
+	return e.Message + ": " + strings.Join(msgs, "; ")
+}
+
+// Validator is implemented by types which can validate themselves after binding
+type Validator interface {
+	Validate() error
+}
+
+// Bind decodes request's JSON body into v.
+// Struct fields tagged with `bind:"required"` must be present in the body.
+func Bind(request *Request, v any, optFns ...func(options *BindOptions)) error {
+	options := new(BindOptions)
+	for _, fn := range optFns {
+		fn(options)
+	}
+
+	body := []byte(request.Body)
+	if request.IsBase64Encoded {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(request.Body)
+		if err != nil {
+			return &BindError{Message: "invalid base64 body"}
+		}
+	}
+	if err := checkJSONLimits(body, options); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if options.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return newBindError(err)
+	}
+
+	var raw any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return newBindError(err)
+	}
+	var fields []*FieldError
+	checkRequired(reflect.TypeOf(v), raw, "", &fields)
+	if len(fields) != 0 {
+		return &BindError{
+			Message: "missing required fields",
+			Fields:  fields,
+		}
+	}
+	return nil
+}
+
+// BindValid binds request's body into v and then calls v.Validate if v implements Validator
+func BindValid(request *Request, v any, optFns ...func(options *BindOptions)) error {
+	if err := Bind(request, v, optFns...); err != nil {
+		return err
+	}
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			var bindErr *BindError
+			if errors.As(err, &bindErr) {
+				return bindErr
+			}
+			return &BindError{Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// checkJSONLimits scans body's tokens without decoding values, so that malicious bodies are rejected cheaply
+func checkJSONLimits(body []byte, options *BindOptions) *BindError {
+	if options.MaxSize > 0 && len(body) > options.MaxSize {
+		return &BindError{
+			Message: fmt.Sprintf("body is larger than %d bytes", options.MaxSize),
+			Status:  http.StatusRequestEntityTooLarge,
+		}
+	}
+	if options.MaxDepth <= 0 && options.MaxTokens <= 0 {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	depth, tokens := 0, 0
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return newBindError(err)
+		}
+		tokens++
+		if options.MaxTokens > 0 && tokens > options.MaxTokens {
+			return &BindError{Message: fmt.Sprintf("body has more than %d tokens", options.MaxTokens)}
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			if options.MaxDepth > 0 && depth > options.MaxDepth {
+				return &BindError{Message: fmt.Sprintf("body is nested deeper than %d", options.MaxDepth)}
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
+
+func newBindError(err error) *BindError {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr):
+		return &BindError{
+			Message: "invalid field type",
+			Fields: []*FieldError{{
+				Field:  typeErr.Field,
+				Reason: fmt.Sprintf("expect %s, got %s", typeErr.Type, typeErr.Value),
+			}},
+		}
+	case errors.As(err, &syntaxErr):
+		return &BindError{Message: fmt.Sprintf("invalid JSON at offset %d", syntaxErr.Offset)}
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return &BindError{Message: "incomplete JSON body"}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &BindError{
+			Message: "unknown field",
+			Fields:  []*FieldError{{Field: field, Reason: "unknown field"}},
+		}
+	default:
+		return &BindError{Message: err.Error()}
+	}
+}
+
+func checkRequired(t reflect.Type, raw any, path string, fields *[]*FieldError) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return
+		}
+		checkStructRequired(t, obj, path, fields)
+	case reflect.Slice, reflect.Array:
+		arr, ok := raw.([]any)
+		if !ok {
+			return
+		}
+		for i, item := range arr {
+			checkRequired(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i), fields)
+		}
+	}
+}
+
+func checkStructRequired(t reflect.Type, obj map[string]any, path string, fields *[]*FieldError) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// like encoding/json, fields of embedded structs are promoted even if the struct type is unexported,
+		// unless it's embedded by pointer
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer && f.IsExported() {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				checkStructRequired(ft, obj, path, fields)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+
+		val, ok := lookupField(obj, name)
+		if !ok || val == nil {
+			if f.Tag.Get("bind") == "required" {
+				*fields = append(*fields, &FieldError{Field: fieldPath, Reason: "missing required field"})
+			}
+			continue
+		}
+		checkRequired(f.Type, val, fieldPath, fields)
+	}
+}
+
+// lookupField finds field name in obj case-insensitively, which is consistent with encoding/json
+func lookupField(obj map[string]any, name string) (any, bool) {
+	if v, ok := obj[name]; ok {
+		return v, true
+	}
+	for k, v := range obj {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func bindErrorResponse(err *BindError) *Response {
+	if err.Status != 0 {
+		return JSON(err.Status, err)
+	}
+	return JSON(http.StatusBadRequest, err)
+}
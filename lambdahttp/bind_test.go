@@ -0,0 +1,184 @@
+package lambdahttp_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.olapie.com/awskit/lambdahttp"
+	"github.com/stretchr/testify/require"
+)
+
+type bindAddress struct {
+	City string `json:"city" bind:"required"`
+	Zip  string `json:"zip"`
+}
+
+type bindBase struct {
+	ID string `json:"id" bind:"required"`
+}
+
+type bindUser struct {
+	bindBase
+	Name    string         `json:"name" bind:"required"`
+	Age     int            `json:"age"`
+	Address *bindAddress   `json:"address"`
+	Items   []*bindAddress `json:"items"`
+	Secret  string         `json:"-" bind:"required"`
+}
+
+type validatedUser struct {
+	Name string `json:"name"`
+}
+
+func (u *validatedUser) Validate() error {
+	if u.Name == "admin" {
+		return errors.New("reserved name")
+	}
+	return nil
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		options func(options *lambdahttp.BindOptions)
+		status  int
+		message string
+		fields  []*lambdahttp.FieldError
+	}{
+		{
+			name: "valid",
+			body: `{"id":"1","name":"tom","age":3,"address":{"city":"paris"},"items":[{"city":"rome"}]}`,
+		},
+		{
+			name: "case insensitive field",
+			body: `{"ID":"1","Name":"tom"}`,
+		},
+		{
+			name:    "missing required",
+			body:    `{"age":3}`,
+			message: "missing required fields",
+			fields: []*lambdahttp.FieldError{
+				{Field: "id", Reason: "missing required field"},
+				{Field: "name", Reason: "missing required field"},
+			},
+		},
+		{
+			name:    "null required",
+			body:    `{"id":"1","name":null}`,
+			message: "missing required fields",
+			fields:  []*lambdahttp.FieldError{{Field: "name", Reason: "missing required field"}},
+		},
+		{
+			name:    "missing nested required",
+			body:    `{"id":"1","name":"tom","address":{"zip":"1"},"items":[{"city":"rome"},{}]}`,
+			message: "missing required fields",
+			fields: []*lambdahttp.FieldError{
+				{Field: "address.city", Reason: "missing required field"},
+				{Field: "items[1].city", Reason: "missing required field"},
+			},
+		},
+		{
+			name:    "invalid type",
+			body:    `{"id":"1","name":"tom","age":"3"}`,
+			message: "invalid field type",
+			fields:  []*lambdahttp.FieldError{{Field: "age", Reason: "expect int, got string"}},
+		},
+		{
+			name:    "syntax error",
+			body:    `{"id":"1",}`,
+			message: "invalid JSON at offset 11",
+		},
+		{
+			name:    "incomplete",
+			body:    `{"id":"1"`,
+			message: "incomplete JSON body",
+		},
+		{
+			name:    "unknown field",
+			body:    `{"id":"1","name":"tom","nickname":"t"}`,
+			options: func(options *lambdahttp.BindOptions) { options.DisallowUnknownFields = true },
+			message: "unknown field",
+			fields:  []*lambdahttp.FieldError{{Field: "nickname", Reason: "unknown field"}},
+		},
+		{
+			name:    "too large",
+			body:    `{"id":"1","name":"tom"}`,
+			options: func(options *lambdahttp.BindOptions) { options.MaxSize = 10 },
+			status:  http.StatusRequestEntityTooLarge,
+			message: "body is larger than 10 bytes",
+		},
+		{
+			name:    "too deep",
+			body:    `{"id":"1","name":"tom","items":[{"city":"rome"}]}`,
+			options: func(options *lambdahttp.BindOptions) { options.MaxDepth = 2 },
+			message: "body is nested deeper than 2",
+		},
+		{
+			name:    "too many tokens",
+			body:    `{"id":"1","name":"tom"}`,
+			options: func(options *lambdahttp.BindOptions) { options.MaxTokens = 4 },
+			message: "body has more than 4 tokens",
+		},
+		{
+			name: "within limits",
+			body: `{"id":"1","name":"tom"}`,
+			options: func(options *lambdahttp.BindOptions) {
+				options.MaxSize, options.MaxDepth, options.MaxTokens = 100, 1, 6
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var optFns []func(options *lambdahttp.BindOptions)
+			if tt.options != nil {
+				optFns = append(optFns, tt.options)
+			}
+			var user bindUser
+			err := lambdahttp.Bind(&lambdahttp.Request{Body: tt.body}, &user, optFns...)
+			if tt.message == "" {
+				require.NoError(t, err)
+				require.Equal(t, "1", user.ID)
+				return
+			}
+			var bindErr *lambdahttp.BindError
+			require.True(t, errors.As(err, &bindErr), err)
+			require.Equal(t, tt.status, bindErr.Status)
+			require.Equal(t, tt.message, bindErr.Message)
+			require.Equal(t, tt.fields, bindErr.Fields)
+		})
+	}
+}
+
+func TestBind_Base64(t *testing.T) {
+	var user bindUser
+	request := &lambdahttp.Request{
+		Body:            base64.StdEncoding.EncodeToString([]byte(`{"id":"1","name":"tom"}`)),
+		IsBase64Encoded: true,
+	}
+	require.NoError(t, lambdahttp.Bind(request, &user))
+	require.Equal(t, "tom", user.Name)
+
+	request.Body = "not base64!"
+	err := lambdahttp.Bind(request, &user)
+	require.EqualError(t, err, "invalid base64 body")
+}
+
+func TestBindValid(t *testing.T) {
+	var user validatedUser
+	require.NoError(t, lambdahttp.BindValid(&lambdahttp.Request{Body: `{"name":"tom"}`}, &user))
+
+	err := lambdahttp.BindValid(&lambdahttp.Request{Body: `{"name":"admin"}`}, &user)
+	var bindErr *lambdahttp.BindError
+	require.True(t, errors.As(err, &bindErr))
+	require.Equal(t, "reserved name", bindErr.Message)
+
+	err = lambdahttp.BindValid(&lambdahttp.Request{Body: strings.Repeat("[", 10)}, &user, func(options *lambdahttp.BindOptions) {
+		options.MaxDepth = 5
+	})
+	require.True(t, errors.As(err, &bindErr))
+	require.Equal(t, "body is nested deeper than 5", bindErr.Message)
+}
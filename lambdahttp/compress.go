@@ -0,0 +1,132 @@
+package lambdahttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+
+	"code.olapie.com/sugar/v2/xhttp"
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	keyAcceptEncoding  = "Accept-Encoding"
+	keyContentEncoding = "Content-Encoding"
+	keyVary            = "Vary"
+
+	encodingBrotli   = "br"
+	encodingGzip     = "gzip"
+	encodingIdentity = "identity"
+)
+
+// Compress compresses response bodies of at least minSize bytes with the encoding negotiated from Accept-Encoding.
+// Brotli is preferred over gzip if the client accepts both with the same quality. Compressed bodies are base64 encoded.
+// Responses to HEAD, 204 and 304 responses, and responses which already have Content-Encoding are left as is.
+func Compress(minSize int) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		resp := Next(ctx, request)
+		if resp == nil || request.RequestContext.HTTP.Method == http.MethodHead ||
+			resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified ||
+			xhttp.GetHeader(resp.Headers, keyContentEncoding) != "" {
+			return resp
+		}
+
+		encoding := negotiateEncoding(xhttp.GetHeader(request.Headers, keyAcceptEncoding))
+		if encoding == encodingIdentity {
+			return resp
+		}
+
+		body := []byte(resp.Body)
+		if resp.IsBase64Encoded {
+			decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+			if err != nil {
+				return resp
+			}
+			body = decoded
+		}
+
+		if len(body) < minSize {
+			return resp
+		}
+
+		compressed, err := compressBody(encoding, body)
+		if err != nil {
+			return resp
+		}
+
+		if resp.Headers == nil {
+			resp.Headers = make(map[string]string)
+		}
+		resp.Headers[keyContentEncoding] = encoding
+		addVary(resp.Headers, keyAcceptEncoding)
+		resp.Body = base64.StdEncoding.EncodeToString(compressed)
+		resp.IsBase64Encoded = true
+		return resp
+	}
+}
+
+// addVary appends field to Vary, keeping fields set by other middlewares or handlers, e.g. Origin
+func addVary(headers map[string]string, field string) {
+	for k, v := range headers {
+		if !strings.EqualFold(k, keyVary) {
+			continue
+		}
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f == "*" || strings.EqualFold(f, field) {
+				return
+			}
+		}
+		if strings.TrimSpace(v) == "" {
+			headers[k] = field
+		} else {
+			headers[k] = v + ", " + field
+		}
+		return
+	}
+	headers[keyVary] = field
+}
+
+func compressBody(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	if encoding == encodingBrotli {
+		w = brotli.NewWriter(&buf)
+	} else {
+		w = gzip.NewWriter(&buf)
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// negotiateEncoding returns br, gzip or identity according to accept-encoding q-values
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := make(map[string]float64)
+	for _, item := range parseQualityList(acceptEncoding) {
+		qualities[item.value] = item.quality
+	}
+
+	if q, ok := qualities["*"]; ok {
+		for _, name := range []string{encodingBrotli, encodingGzip} {
+			if _, ok := qualities[name]; !ok {
+				qualities[name] = q
+			}
+		}
+	}
+
+	best, bestQ := encodingIdentity, 0.0
+	for _, name := range []string{encodingBrotli, encodingGzip} {
+		if q := qualities[name]; q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
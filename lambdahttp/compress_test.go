@@ -0,0 +1,68 @@
+package lambdahttp_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.olapie.com/awskit/lambdahttp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("hello ", 100)
+	tests := []struct {
+		name       string
+		method     string
+		status     int
+		headers    map[string]string
+		body       string
+		encoding   string
+		compressed bool
+	}{
+		{"gzip", http.MethodGet, http.StatusOK, nil, body, "gzip", true},
+		{"too small", http.MethodGet, http.StatusOK, nil, "hello", "gzip", false},
+		{"identity", http.MethodGet, http.StatusOK, nil, body, "identity", false},
+		{"head", http.MethodHead, http.StatusOK, nil, body, "gzip", false},
+		{"no content", http.MethodGet, http.StatusNoContent, nil, body, "gzip", false},
+		{"not modified", http.MethodGet, http.StatusNotModified, nil, body, "gzip", false},
+		{"already encoded", http.MethodGet, http.StatusOK, map[string]string{"Content-Encoding": "br"}, body, "gzip", false},
+		{"already encoded lower case", http.MethodGet, http.StatusOK, map[string]string{"content-encoding": "br"}, body, "gzip", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := lambdahttp.NewRouter()
+			r.Use(lambdahttp.Compress(64))
+			r.HandleWithMeta(tt.method, "/", nil, func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+				resp := lambdahttp.Text(tt.status, tt.body)
+				for k, v := range tt.headers {
+					resp.Headers[k] = v
+				}
+				return resp
+			})
+			request := newTestRequest(tt.method, "/")
+			request.Headers["accept-encoding"] = tt.encoding
+			resp := r.Handle(context.Background(), request)
+			if !tt.compressed {
+				require.Equal(t, tt.body, resp.Body)
+				require.False(t, resp.IsBase64Encoded)
+				return
+			}
+
+			require.Equal(t, "gzip", resp.Headers["Content-Encoding"])
+			require.Equal(t, "Accept-Encoding", resp.Headers["Vary"])
+			require.True(t, resp.IsBase64Encoded)
+			compressed, err := base64.StdEncoding.DecodeString(resp.Body)
+			require.NoError(t, err)
+			zr, err := gzip.NewReader(strings.NewReader(string(compressed)))
+			require.NoError(t, err)
+			plain, err := io.ReadAll(zr)
+			require.NoError(t, err)
+			require.Equal(t, tt.body, string(plain))
+		})
+	}
+}
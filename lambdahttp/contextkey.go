@@ -0,0 +1,49 @@
+package lambdahttp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+var contextKeyNames sync.Map
+
+// ContextKey is a typed key for sharing request-scoped values between middlewares and handlers.
+// Keys are compared by identity, so values set by different packages never collide.
+type ContextKey[T any] struct {
+	name string
+}
+
+// NewContextKey creates a key. It panics if name has been registered, as keys are supposed to be package variables
+func NewContextKey[T any](name string) *ContextKey[T] {
+	if _, loaded := contextKeyNames.LoadOrStore(name, struct{}{}); loaded {
+		panic(fmt.Sprintf("duplicate context key %s", name))
+	}
+	return &ContextKey[T]{name: name}
+}
+
+func (k *ContextKey[T]) Name() string {
+	return k.name
+}
+
+func (k *ContextKey[T]) Set(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+func (k *ContextKey[T]) Get(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+// Identity is the authenticated caller
+type Identity struct {
+	ID     string
+	Scopes []string
+}
+
+var (
+	IdentityKey     = NewContextKey[*Identity]("identity")
+	TraceIDKey      = NewContextKey[string]("trace_id")
+	RoutePatternKey = NewContextKey[string]("route_pattern")
+	RouteMetaKey    = NewContextKey[map[string]string]("route_meta")
+)
@@ -0,0 +1,84 @@
+package lambdahttp
+
+import (
+	"context"
+	"net/http"
+
+	"code.olapie.com/log"
+	"code.olapie.com/sugar/v2/xhttp"
+	"github.com/google/uuid"
+)
+
+const KeyCorrelationID = "X-Correlation-ID"
+
+// maxCorrelationIDLength bounds correlation ids accepted from clients as they're logged and forwarded
+const maxCorrelationIDLength = 128
+
+var CorrelationIDKey = NewContextKey[string]("correlation_id")
+
+// Correlate propagates X-Correlation-ID: it takes the request's correlation id, or generates one if it's missing or invalid,
+// stores it in context, adds it to the context logger and sets it on the response.
+// Outgoing requests carry it if they're sent by a client wrapped by CorrelationTransport.
+func Correlate() Func {
+	return func(ctx context.Context, request *Request) *Response {
+		id := xhttp.GetHeader(request.Headers, KeyCorrelationID)
+		if !isValidCorrelationID(id) {
+			id = uuid.NewString()
+		}
+		logger := log.FromContext(ctx).With(log.String("correlation_id", id))
+		ctx = log.BuildContext(CorrelationIDKey.Set(ctx, id), logger)
+
+		resp := Next(ctx, request)
+		if resp == nil {
+			return resp
+		}
+		if resp.Headers == nil {
+			resp.Headers = make(map[string]string)
+		}
+		resp.Headers[KeyCorrelationID] = id
+		return resp
+	}
+}
+
+// CorrelationID returns the correlation id stored by Correlate, or empty string
+func CorrelationID(ctx context.Context) string {
+	id, _ := CorrelationIDKey.Get(ctx)
+	return id
+}
+
+func isValidCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// CorrelationTransport sets X-Correlation-ID on outgoing requests whose context has a correlation id.
+// It works for both plain HTTP clients and AWS SDK clients, e.g.
+//
+//	client := &http.Client{Transport: &CorrelationTransport{}}
+//	s3.NewFromConfig(cfg, func(o *s3.Options) { o.HTTPClient = client })
+type CorrelationTransport struct {
+	// Base defaults to http.DefaultTransport
+	Base http.RoundTripper
+}
+
+func (t *CorrelationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	id := CorrelationID(req.Context())
+	if id == "" || req.Header.Get(KeyCorrelationID) != "" {
+		return base.RoundTrip(req)
+	}
+	// RoundTrippers must not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Set(KeyCorrelationID, id)
+	return base.RoundTrip(req)
+}
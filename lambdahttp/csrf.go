@@ -0,0 +1,109 @@
+package lambdahttp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.olapie.com/sugar/v2/xhttp"
+)
+
+type CSRFOptions struct {
+	// CookieName defaults to csrf_token
+	CookieName string
+
+	// HeaderName is the header which carries the submitted token. Defaults to X-CSRF-Token.
+	HeaderName string
+
+	// Path of the cookie. Defaults to /
+	Path string
+
+	// MaxAge of the cookie in seconds. Zero means a session cookie.
+	MaxAge int
+
+	// Methods to check. Defaults to POST, PUT, PATCH and DELETE.
+	Methods []string
+}
+
+func newCSRFOptions(optFns []func(options *CSRFOptions)) *CSRFOptions {
+	options := &CSRFOptions{
+		CookieName: "csrf_token",
+		HeaderName: "X-CSRF-Token",
+		Path:       "/",
+		Methods:    []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+	}
+	for _, fn := range optFns {
+		fn(options)
+	}
+	return options
+}
+
+// IssueCSRFToken signs a random token with privKey and sets it as a cookie on resp.
+// The cookie isn't HttpOnly so that scripts can read and submit it in the header, i.e. double-submit.
+// The token is also returned, e.g. to embed in a form.
+func IssueCSRFToken(resp *Response, privKey *ecdsa.PrivateKey, optFns ...func(options *CSRFOptions)) (string, error) {
+	options := newCSRFOptions(optFns)
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("rand: %w", err)
+	}
+	sign, err := signMessage(privKey, nonce)
+	if err != nil {
+		return "", fmt.Errorf("sign: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(nonce) + "." + base64.RawURLEncoding.EncodeToString(sign)
+	cookie := &http.Cookie{
+		Name:     options.CookieName,
+		Value:    token,
+		Path:     options.Path,
+		MaxAge:   options.MaxAge,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	}
+	resp.Cookies = append(resp.Cookies, cookie.String())
+	return token, nil
+}
+
+// VerifyCSRF rejects requests with mutating methods with 403 unless the token in the header equals the cookie
+// and the cookie is signed by the counterpart of pubKey, which prevents attackers from planting their own tokens.
+func VerifyCSRF(pubKey *ecdsa.PublicKey, optFns ...func(options *CSRFOptions)) Func {
+	options := newCSRFOptions(optFns)
+	return func(ctx context.Context, request *Request) *Response {
+		if !containsFold(options.Methods, request.RequestContext.HTTP.Method) {
+			return Next(ctx, request)
+		}
+		cookie, ok := Cookie(request, options.CookieName)
+		if !ok || cookie == "" {
+			return errorStatus(http.StatusForbidden, "missing csrf cookie")
+		}
+		submitted := xhttp.GetHeader(request.Headers, options.HeaderName)
+		if subtle.ConstantTimeCompare([]byte(submitted), []byte(cookie)) != 1 {
+			return errorStatus(http.StatusForbidden, "csrf token mismatch")
+		}
+		if !verifyCSRFToken(pubKey, cookie) {
+			return errorStatus(http.StatusForbidden, "invalid csrf token")
+		}
+		return Next(ctx, request)
+	}
+}
+
+func verifyCSRFToken(pubKey *ecdsa.PublicKey, token string) bool {
+	encodedNonce, encodedSign, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	nonce, err := base64.RawURLEncoding.DecodeString(encodedNonce)
+	if err != nil {
+		return false
+	}
+	sign, err := base64.RawURLEncoding.DecodeString(encodedSign)
+	if err != nil {
+		return false
+	}
+	return verifyMessage(pubKey, nonce, sign)
+}
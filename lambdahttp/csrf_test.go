@@ -0,0 +1,114 @@
+package lambdahttp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.olapie.com/awskit/lambdahttp"
+	"github.com/stretchr/testify/require"
+)
+
+func issueTestCSRFToken(t *testing.T, key *ecdsa.PrivateKey) string {
+	resp := lambdahttp.Status(http.StatusOK)
+	token, err := lambdahttp.IssueCSRFToken(resp, key, func(options *lambdahttp.CSRFOptions) {
+		options.MaxAge = 3600
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Cookies, 1)
+	cookie := resp.Cookies[0]
+	require.True(t, strings.HasPrefix(cookie, "csrf_token="+token+";"), cookie)
+	require.Contains(t, cookie, "Max-Age=3600")
+	require.Contains(t, cookie, "Secure")
+	require.Contains(t, cookie, "SameSite=Strict")
+	require.NotContains(t, cookie, "HttpOnly")
+	return token
+}
+
+func TestVerifyCSRF(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	token := issueTestCSRFToken(t, key)
+	forged := issueTestCSRFToken(t, otherKey)
+	tampered := token[:len(token)-2] + "AA"
+	if tampered == token {
+		tampered = token[:len(token)-2] + "BB"
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		cookies []string
+		headers map[string]string
+		status  int
+	}{
+		{"safe method", http.MethodGet, nil, nil, http.StatusOK},
+		{"valid", http.MethodPost, []string{"csrf_token=" + token}, map[string]string{"x-csrf-token": token}, http.StatusOK},
+		{"cookie header", http.MethodDelete, nil, map[string]string{
+			"Cookie":       "a=1; csrf_token=" + token,
+			"X-CSRF-Token": token,
+		}, http.StatusOK},
+		{"missing cookie", http.MethodPost, nil, map[string]string{"X-CSRF-Token": token}, http.StatusForbidden},
+		{"missing header", http.MethodPut, []string{"csrf_token=" + token}, nil, http.StatusForbidden},
+		{"mismatch", http.MethodPatch, []string{"csrf_token=" + token}, map[string]string{"X-CSRF-Token": forged}, http.StatusForbidden},
+		{"planted token", http.MethodPost, []string{"csrf_token=" + forged}, map[string]string{"X-CSRF-Token": forged}, http.StatusForbidden},
+		{"tampered token", http.MethodPost, []string{"csrf_token=" + tampered}, map[string]string{"X-CSRF-Token": tampered}, http.StatusForbidden},
+		{"malformed token", http.MethodPost, []string{"csrf_token=abc"}, map[string]string{"X-CSRF-Token": "abc"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := lambdahttp.NewRouter()
+			r.Use(lambdahttp.VerifyCSRF(&key.PublicKey))
+			r.HandleWithMeta(tt.method, "/", nil, func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+				return lambdahttp.Status(http.StatusOK)
+			})
+			request := newTestRequest(tt.method, "/")
+			request.Cookies = tt.cookies
+			for k, v := range tt.headers {
+				request.Headers[k] = v
+			}
+			resp := r.Handle(context.Background(), request)
+			require.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestVerifyCSRF_Options(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	setOptions := func(options *lambdahttp.CSRFOptions) {
+		options.CookieName = "xsrf"
+		options.HeaderName = "X-XSRF-Token"
+		options.Methods = []string{http.MethodPost}
+	}
+	resp := lambdahttp.Status(http.StatusOK)
+	token, err := lambdahttp.IssueCSRFToken(resp, key, setOptions)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(resp.Cookies[0], "xsrf="+token))
+
+	r := lambdahttp.NewRouter()
+	r.Use(lambdahttp.VerifyCSRF(&key.PublicKey, setOptions))
+	handler := func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+		return lambdahttp.Status(http.StatusOK)
+	}
+	r.HandleWithMeta(http.MethodPost, "/", nil, handler)
+	r.HandleWithMeta(http.MethodDelete, "/", nil, handler)
+
+	request := newTestRequest(http.MethodPost, "/")
+	request.Cookies = []string{"xsrf=" + token}
+	request.Headers["X-XSRF-Token"] = token
+	require.Equal(t, http.StatusOK, r.Handle(context.Background(), request).StatusCode)
+
+	request = newTestRequest(http.MethodPost, "/")
+	require.Equal(t, http.StatusForbidden, r.Handle(context.Background(), request).StatusCode)
+
+	// methods not in Methods aren't checked
+	request = newTestRequest(http.MethodDelete, "/")
+	require.Equal(t, http.StatusOK, r.Handle(context.Background(), request).StatusCode)
+}
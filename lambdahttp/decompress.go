@@ -0,0 +1,87 @@
+package lambdahttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"code.olapie.com/sugar/v2/xerror"
+	"code.olapie.com/sugar/v2/xhttp"
+	"github.com/andybalholm/brotli"
+)
+
+// Decompress decodes request bodies compressed with gzip or br according to Content-Encoding.
+// The decoded body replaces request.Body (base64 encoded) and Content-Encoding is removed,
+// so handlers and later middlewares see the plain body. Bodies decompressed beyond maxSize bytes are rejected with 413.
+func Decompress(maxSize int64) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		if xhttp.GetHeader(request.Headers, keyContentEncoding) == "" {
+			return Next(ctx, request)
+		}
+		body, err := decodeRequestBody(request, maxSize)
+		if err != nil {
+			return Error(err)
+		}
+		request.Body = base64.StdEncoding.EncodeToString(body)
+		request.IsBase64Encoded = true
+		for k := range request.Headers {
+			if strings.EqualFold(k, keyContentEncoding) {
+				delete(request.Headers, k)
+			}
+		}
+		return Next(ctx, request)
+	}
+}
+
+// decodeRequestBody returns the plain request body, i.e. base64 decoded and decompressed per Content-Encoding.
+// maxSize limits the decompressed size if positive.
+func decodeRequestBody(request *Request, maxSize int64) ([]byte, error) {
+	body := []byte(request.Body)
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(request.Body)
+		if err != nil {
+			return nil, xerror.BadRequest("invalid base64 body")
+		}
+		body = decoded
+	}
+
+	var r io.Reader
+	switch encoding := strings.ToLower(strings.TrimSpace(xhttp.GetHeader(request.Headers, keyContentEncoding))); encoding {
+	case "", encodingIdentity:
+		return body, nil
+	case encodingGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, xerror.BadRequest("invalid gzip body")
+		}
+		defer gr.Close()
+		r = gr
+	case encodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return nil, &xerror.Error{
+			Code:    http.StatusUnsupportedMediaType,
+			Message: fmt.Sprintf("unsupported content encoding %s", encoding),
+		}
+	}
+
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return nil, xerror.BadRequest("invalid compressed body")
+	}
+	if maxSize > 0 && int64(len(plain)) > maxSize {
+		return nil, &xerror.Error{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("decompressed body exceeds %d bytes", maxSize),
+		}
+	}
+	return plain, nil
+}
@@ -0,0 +1,143 @@
+package lambdahttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"code.olapie.com/log"
+)
+
+const KeyDuplicateRequest = "X-Duplicate-Request"
+
+// ResponseStore caches responses of deduplicated requests. Get returns nil if key is absent or expired.
+// Stores shared by multiple Lambda instances, e.g. DynamoDB with TTL, make deduplication work across instances.
+type ResponseStore interface {
+	Get(ctx context.Context, key string) (*Response, error)
+	Put(ctx context.Context, key string, resp *Response, ttl time.Duration) error
+}
+
+type cachedResponse struct {
+	resp      *Response
+	expiresAt time.Time
+}
+
+// MemoryResponseStore keeps responses in memory of the current Lambda instance
+type MemoryResponseStore struct {
+	mu        sync.Mutex
+	responses map[string]*cachedResponse
+}
+
+var _ ResponseStore = (*MemoryResponseStore)(nil)
+
+func NewMemoryResponseStore() *MemoryResponseStore {
+	return &MemoryResponseStore{
+		responses: make(map[string]*cachedResponse),
+	}
+}
+
+func (s *MemoryResponseStore) Get(ctx context.Context, key string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cached, ok := s.responses[key]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(cached.expiresAt) {
+		delete(s.responses, key)
+		return nil, nil
+	}
+	return copyResponse(cached.resp), nil
+}
+
+func (s *MemoryResponseStore) Put(ctx context.Context, key string, resp *Response, ttl time.Duration) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// evict expired responses on write, so that the map doesn't grow with unique requests
+	for k, cached := range s.responses {
+		if now.After(cached.expiresAt) {
+			delete(s.responses, k)
+		}
+	}
+	s.responses[key] = &cachedResponse{resp: copyResponse(resp), expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// DedupeRequests replays the response of an identical POST, PUT, PATCH or DELETE request received within ttl
+// instead of invoking the following handlers again, to protect non-idempotent endpoints from double submits
+// by clients which retry without idempotency keys. Requests are identical if they have the same caller (see IdentityKey),
+// method, path, query and body. Only 2xx responses are cached so that failed requests can be retried,
+// and replayed responses have header X-Duplicate-Request: true.
+// Identical requests arriving concurrently aren't deduplicated, as neither has responded yet.
+func DedupeRequests(store ResponseStore, ttl time.Duration) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		switch request.RequestContext.HTTP.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			return Next(ctx, request)
+		}
+
+		key := requestHash(ctx, request)
+		cached, err := store.Get(ctx, key)
+		if err != nil {
+			log.FromContext(ctx).Warn("Cannot get cached response", log.Error(err))
+		} else if cached != nil {
+			log.FromContext(ctx).Info("Duplicate request", log.String("request_hash", key))
+			if cached.Headers == nil {
+				cached.Headers = make(map[string]string)
+			}
+			cached.Headers[KeyDuplicateRequest] = "true"
+			return cached
+		}
+
+		resp := Next(ctx, request)
+		if resp == nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return resp
+		}
+		if err = store.Put(ctx, key, resp, ttl); err != nil {
+			log.FromContext(ctx).Warn("Cannot cache response", log.Error(err))
+		}
+		return resp
+	}
+}
+
+// requestHash returns hex encoded sha256 of caller, method, path, query and body, separated by NUL
+func requestHash(ctx context.Context, request *Request) string {
+	h := sha256.New()
+	if identity, ok := IdentityKey.Get(ctx); ok && identity != nil {
+		h.Write([]byte(identity.ID))
+	}
+	for _, s := range []string{
+		request.RequestContext.HTTP.Method,
+		request.RawPath,
+		request.RawQueryString,
+		request.Body,
+	} {
+		h.Write([]byte{0})
+		h.Write([]byte(s))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// copyResponse copies resp and its header maps, so that cached responses aren't modified by middlewares
+func copyResponse(resp *Response) *Response {
+	c := *resp
+	if resp.Headers != nil {
+		c.Headers = make(map[string]string, len(resp.Headers))
+		for k, v := range resp.Headers {
+			c.Headers[k] = v
+		}
+	}
+	if resp.MultiValueHeaders != nil {
+		c.MultiValueHeaders = make(map[string][]string, len(resp.MultiValueHeaders))
+		for k, v := range resp.MultiValueHeaders {
+			c.MultiValueHeaders[k] = append([]string(nil), v...)
+		}
+	}
+	c.Cookies = append([]string(nil), resp.Cookies...)
+	return &c
+}
@@ -0,0 +1,54 @@
+package lambdahttp
+
+import (
+	"context"
+	"net/http"
+
+	"code.olapie.com/sugar/v2/xcontext"
+	"code.olapie.com/sugar/v2/xerror"
+)
+
+// EnvelopeBody is the response contract produced by Envelope and ErrorEnvelope
+type EnvelopeBody struct {
+	Data    any           `json:"data,omitempty"`
+	Error   *xerror.Error `json:"error,omitempty"`
+	TraceID string        `json:"trace_id,omitempty"`
+}
+
+// Envelope wraps data as {"data": ..., "trace_id": ...}
+func Envelope(ctx context.Context, status int, data any) *Response {
+	return JSON(status, &EnvelopeBody{
+		Data:    data,
+		TraceID: xcontext.GetTraceID(ctx),
+	})
+}
+
+// ErrorEnvelope wraps err as {"error": {"code": ..., "message": ...}, "trace_id": ...}
+func ErrorEnvelope(ctx context.Context, err error) *Response {
+	if err == nil {
+		return Envelope(ctx, http.StatusOK, nil)
+	}
+
+	er, ok := err.(*xerror.Error)
+	if !ok {
+		er = &xerror.Error{
+			Code:    xerror.GetCode(err),
+			Message: err.Error(),
+		}
+		if er.Code == 0 {
+			er.Code = http.StatusInternalServerError
+		}
+	}
+	return JSON(httpErrorStatus(er.Code), &EnvelopeBody{
+		Error:   er,
+		TraceID: xcontext.GetTraceID(ctx),
+	})
+}
+
+// EnvelopeOrError returns ErrorEnvelope if err is not nil, otherwise Envelope with status 200
+func EnvelopeOrError(ctx context.Context, data any, err error) *Response {
+	if err != nil {
+		return ErrorEnvelope(ctx, err)
+	}
+	return Envelope(ctx, http.StatusOK, data)
+}
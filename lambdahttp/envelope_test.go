@@ -0,0 +1,45 @@
+package lambdahttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"code.olapie.com/awskit/lambdahttp"
+	"code.olapie.com/sugar/v2/xerror"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   int
+	}{
+		{"nil", nil, http.StatusOK, 0},
+		{"plain error", errors.New("failed"), http.StatusInternalServerError, http.StatusInternalServerError},
+		{"client error", &xerror.Error{Code: http.StatusNotFound, Message: "not found"}, http.StatusNotFound, http.StatusNotFound},
+		{"server error", &xerror.Error{Code: http.StatusServiceUnavailable}, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		{"application code", &xerror.Error{Code: 10001, Message: "quota"}, http.StatusInternalServerError, 10001},
+		{"success code", &xerror.Error{Code: http.StatusOK}, http.StatusInternalServerError, http.StatusOK},
+		{"negative code", &xerror.Error{Code: -1}, http.StatusInternalServerError, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := lambdahttp.ErrorEnvelope(context.Background(), tt.err)
+			require.Equal(t, tt.status, resp.StatusCode)
+			var body lambdahttp.EnvelopeBody
+			require.NoError(t, json.Unmarshal([]byte(resp.Body), &body))
+			if tt.err == nil {
+				require.Nil(t, body.Error)
+				return
+			}
+			require.Equal(t, tt.code, body.Error.Code)
+
+			require.Equal(t, tt.status, lambdahttp.Error(tt.err).StatusCode)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package lambdahttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	keyIfNoneMatch  = "If-None-Match"
+	keyCacheControl = "Cache-Control"
+)
+
+// ETag sets ETag of successful GET responses from the hash of body unless handlers have set it,
+// and responds with 304 if the request's If-None-Match matches. A weak ETag is prefixed with W/.
+// HEAD responses have no body to hash, so they're only matched if handlers have set ETag.
+func ETag(weak bool) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		resp := Next(ctx, request)
+		method := request.RequestContext.HTTP.Method
+		switch method {
+		case http.MethodGet, http.MethodHead:
+		default:
+			return resp
+		}
+		if resp == nil || resp.StatusCode != http.StatusOK {
+			return resp
+		}
+
+		etag := resp.Headers[keyETag]
+		if etag == "" && method == http.MethodHead {
+			return resp
+		}
+		if resp.Headers == nil {
+			resp.Headers = make(map[string]string)
+		}
+		if etag == "" {
+			sum := sha256.Sum256([]byte(resp.Body))
+			etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+			if weak {
+				etag = "W/" + etag
+			}
+			resp.Headers[keyETag] = etag
+		}
+
+		if matchETag(HeaderValues(request.Headers, keyIfNoneMatch), etag) {
+			notModified := new(Response)
+			notModified.StatusCode = http.StatusNotModified
+			notModified.Headers = map[string]string{keyETag: etag}
+			if v, ok := resp.Headers[keyCacheControl]; ok {
+				notModified.Headers[keyCacheControl] = v
+			}
+			return notModified
+		}
+		return resp
+	}
+}
+
+// matchETag compares with the weak comparison which If-None-Match requires
+func matchETag(candidates []string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, c := range candidates {
+		if c == "*" || strings.TrimPrefix(c, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package lambdahttp
+
+import (
+	"net/http"
+	"strings"
+
+	"code.olapie.com/sugar/v2/xhttp"
+)
+
+const keyCookie = "Cookie"
+
+// HeaderValues returns values of a header which API Gateway may have folded into one value,
+// e.g. Accept: text/html, application/json returns [text/html application/json].
+// Cookie is split on semicolons, other headers on commas outside quoted strings.
+func HeaderValues(headers map[string]string, key string) []string {
+	return SplitHeaderValues(key, xhttp.GetHeader(headers, key))
+}
+
+// SplitHeaderValues splits a folded header value according to the rules of header key
+func SplitHeaderValues(key, value string) []string {
+	sep := byte(',')
+	if strings.EqualFold(key, keyCookie) {
+		sep = ';'
+	}
+
+	var values []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case '\\':
+			if inQuotes {
+				i++
+			}
+		case sep:
+			if !inQuotes {
+				if v := strings.TrimSpace(value[start:i]); v != "" {
+					values = append(values, v)
+				}
+				start = i + 1
+			}
+		}
+	}
+	if start < len(value) {
+		if v := strings.TrimSpace(value[start:]); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// JoinHeaderValues folds values of header key into one value, which is the reverse of SplitHeaderValues
+func JoinHeaderValues(key string, values []string) string {
+	if strings.EqualFold(key, keyCookie) {
+		return strings.Join(values, "; ")
+	}
+	return strings.Join(values, ", ")
+}
+
+// Cookies returns cookies of request, from both request.Cookies where API Gateway v2 moves them and the Cookie header
+func Cookies(request *Request) []*http.Cookie {
+	values := append([]string(nil), request.Cookies...)
+	values = append(values, HeaderValues(request.Headers, keyCookie)...)
+	header := http.Header{keyCookie: []string{JoinHeaderValues(keyCookie, values)}}
+	return (&http.Request{Header: header}).Cookies()
+}
+
+// Cookie returns the value of request's cookie name
+func Cookie(request *Request, name string) (string, bool) {
+	for _, c := range Cookies(request) {
+		if c.Name == name {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
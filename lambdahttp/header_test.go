@@ -0,0 +1,67 @@
+package lambdahttp_test
+
+import (
+	"testing"
+
+	"code.olapie.com/awskit/lambdahttp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitHeaderValues(t *testing.T) {
+	tests := []struct {
+		key    string
+		value  string
+		values []string
+	}{
+		{"Accept", "text/html, application/json", []string{"text/html", "application/json"}},
+		{"Accept", " text/html ,, application/json , ", []string{"text/html", "application/json"}},
+		{"Accept", "", nil},
+		{"Link", `<https://a.com>; title="a, b", <https://b.com>`, []string{`<https://a.com>; title="a, b"`, "<https://b.com>"}},
+		{"If-None-Match", `"a,b", W/"c"`, []string{`"a,b"`, `W/"c"`}},
+		{"X-Custom", `"escaped \" quote, still quoted", next`, []string{`"escaped \" quote, still quoted"`, "next"}},
+		{"X-Custom", `"escaped \\", next`, []string{`"escaped \\"`, "next"}},
+		{"X-Custom", `a\, b`, []string{`a\`, "b"}},
+		{"X-Custom", `"unterminated, quote`, []string{`"unterminated, quote`}},
+		{"Cookie", "a=1; b=2, 3;c=", []string{"a=1", "b=2, 3", "c="}},
+		{"cookie", `a="x;y"; b=2`, []string{`a="x;y"`, "b=2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+" "+tt.value, func(t *testing.T) {
+			values := lambdahttp.SplitHeaderValues(tt.key, tt.value)
+			require.Equal(t, tt.values, values)
+			if len(values) != 0 {
+				require.Equal(t, values, lambdahttp.SplitHeaderValues(tt.key, lambdahttp.JoinHeaderValues(tt.key, values)))
+			}
+		})
+	}
+}
+
+func TestHeaderValues(t *testing.T) {
+	headers := map[string]string{"accept": "text/html, application/json"}
+	require.Equal(t, []string{"text/html", "application/json"}, lambdahttp.HeaderValues(headers, "Accept"))
+	require.Empty(t, lambdahttp.HeaderValues(headers, "Accept-Language"))
+}
+
+func TestCookie(t *testing.T) {
+	request := &lambdahttp.Request{
+		Cookies: []string{"a=1", "b=2"},
+		Headers: map[string]string{"cookie": "c=3; d=4"},
+	}
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+	}{
+		{"a", "1", true},
+		{"b", "2", true},
+		{"c", "3", true},
+		{"d", "4", true},
+		{"e", "", false},
+	}
+	for _, tt := range tests {
+		value, ok := lambdahttp.Cookie(request, tt.name)
+		require.Equal(t, tt.ok, ok, tt.name)
+		require.Equal(t, tt.value, value, tt.name)
+	}
+	require.Len(t, lambdahttp.Cookies(request), 4)
+}
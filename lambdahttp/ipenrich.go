@@ -0,0 +1,66 @@
+package lambdahttp
+
+import (
+	"context"
+
+	"code.olapie.com/log"
+)
+
+// IPInfo is coarse network information about a client IP
+type IPInfo struct {
+	Country string
+	ASN     uint
+	ASOrg   string
+}
+
+// IPEnricher looks up IPInfo of an IP, e.g. with a MaxMind database.
+// It may return nil if the IP is unknown.
+type IPEnricher interface {
+	Enrich(ctx context.Context, ip string) (*IPInfo, error)
+}
+
+type nopIPEnricher struct{}
+
+func (nopIPEnricher) Enrich(ctx context.Context, ip string) (*IPInfo, error) {
+	return nil, nil
+}
+
+// NopIPEnricher doesn't enrich any IP
+var NopIPEnricher IPEnricher = nopIPEnricher{}
+
+var IPInfoKey = NewContextKey[*IPInfo]("ip_info")
+
+// EnrichIP looks up the request's source IP with enricher, then adds country and ASN to the context logger
+// and stores IPInfo in context, which can be read by GetIPInfo. Lookup failures are logged and don't fail the request.
+func EnrichIP(enricher IPEnricher) Func {
+	if enricher == nil {
+		enricher = NopIPEnricher
+	}
+	return func(ctx context.Context, request *Request) *Response {
+		ip := request.RequestContext.HTTP.SourceIP
+		if ip == "" {
+			return Next(ctx, request)
+		}
+		info, err := enricher.Enrich(ctx, ip)
+		if err != nil {
+			log.FromContext(ctx).Warn("Cannot enrich ip", log.String("ip", ip), log.Error(err))
+			return Next(ctx, request)
+		}
+		if info == nil {
+			return Next(ctx, request)
+		}
+		logger := log.FromContext(ctx).With(
+			log.String("country", info.Country),
+			log.Int64("asn", int64(info.ASN)),
+			log.String("as_org", info.ASOrg),
+		)
+		ctx = log.BuildContext(IPInfoKey.Set(ctx, info), logger)
+		return Next(ctx, request)
+	}
+}
+
+// GetIPInfo returns IPInfo stored by EnrichIP, or nil
+func GetIPInfo(ctx context.Context) *IPInfo {
+	info, _ := IPInfoKey.Get(ctx)
+	return info
+}
@@ -5,8 +5,15 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"code.olapie.com/log"
 	"code.olapie.com/router"
@@ -17,21 +24,44 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 )
 
+const keyContentLength = "Content-Length"
+
 type Request = events.APIGatewayV2HTTPRequest
 type Response = events.APIGatewayV2HTTPResponse
 type Func = router.HandlerFunc[*Request, *Response]
 
 type Router struct {
 	*router.Router[Func]
+
+	// SetContentLength makes the router set Content-Length on responses.
+	// It's redundant if the gateway sets it.
+	SetContentLength bool
+
+	// ErrorBodyLogLimit is the max number of bytes of an error response body to log.
+	// Longer bodies are truncated and logged with their full length. Defaults to 1024.
+	ErrorBodyLogLimit int
+
+	// CollapseSlashes makes the router collapse consecutive slashes in RawPath before matching, e.g. /a//b to /a/b.
+	// It's opt-in as empty segments may be meaningful, e.g. in object keys.
+	CollapseSlashes bool
+
+	middlewares  []Func
+	endpoints    []RouteInfo
+	routes       []*route
+	panicMappers []PanicMapper
 }
 
 func NewRouter() *Router {
 	return &Router{
-		Router: router.New[Func](),
+		Router:            router.New[Func](),
+		ErrorBodyLogLimit: 1024,
 	}
 }
 
 func (r *Router) Handle(ctx context.Context, request *Request) (resp *Response) {
+	if r.CollapseSlashes {
+		request.RawPath = collapseSlashes(request.RawPath)
+	}
 	ctx = BuildContext(ctx, request)
 	httpInfo := request.RequestContext.HTTP
 	logger := log.FromContext(ctx)
@@ -47,6 +77,11 @@ func (r *Router) Handle(ctx context.Context, request *Request) (resp *Response)
 	defer func() {
 		if msg := recover(); msg != nil {
 			logger.Error("Panic", log.Any("error", msg))
+			for _, m := range r.panicMappers {
+				if resp = m(msg); resp != nil {
+					return
+				}
+			}
 			resp = Error(errors.New(fmt.Sprint(msg)))
 			return
 		}
@@ -55,10 +90,12 @@ func (r *Router) Handle(ctx context.Context, request *Request) (resp *Response)
 		if resp.StatusCode < 400 {
 			logger.Info("End")
 		} else {
-			if len(resp.Body) < 1024 {
+			if len(resp.Body) <= r.ErrorBodyLogLimit {
 				logger.Error("End", log.String("body", resp.Body))
 			} else {
-				logger.Error("End")
+				logger.Error("End",
+					log.String("body", truncateUTF8(resp.Body, r.ErrorBodyLogLimit)),
+					log.Int("body_length", len(resp.Body)))
 			}
 		}
 
@@ -66,22 +103,76 @@ func (r *Router) Handle(ctx context.Context, request *Request) (resp *Response)
 			resp.Headers = make(map[string]string)
 		}
 		xhttp.SetTraceID(resp.Headers, xcontext.GetTraceID(ctx))
+		// HEAD responses have no body, keep the length of the resource set by handlers
+		if r.SetContentLength && (httpInfo.Method != http.MethodHead || resp.Headers[keyContentLength] == "") {
+			resp.Headers[keyContentLength] = strconv.Itoa(bodyLength(resp))
+		}
 	}()
 
-	endpoint, _ := r.Match(httpInfo.Method, request.RawPath)
-	if endpoint != nil {
-		handler := endpoint.Handler()
-		ctx = router.WithNextHandler(ctx, handler.Next())
-		resp = handler.Handler()(ctx, request)
-		if resp == nil {
-			resp = Error(xerror.NotImplemented("no response from handler"))
+	var handler Func
+	if endpoint, _ := r.Match(httpInfo.Method, request.RawPath); endpoint != nil {
+		node := endpoint.Handler()
+		handler = func(ctx context.Context, request *Request) *Response {
+			// handlers of the endpoint call Next of the underlying router
+			ctx = context.WithValue(ctx, nextHandlersKey{}, nil)
+			ctx = router.WithNextHandler(ctx, node.Next())
+			return node.Handler()(ctx, request)
+		}
+	} else if rt, params := r.matchRoute(httpInfo.Method, request.RawPath); rt != nil {
+		ctx = RoutePatternKey.Set(withPathParams(ctx, params), rt.Path)
+		ctx = RouteMetaKey.Set(ctx, rt.Meta)
+		handler = rt.handler
+	} else {
+		return Error(xerror.NotFound("endpoint not found: %s %s", httpInfo.Method, request.RawPath))
+	}
+
+	handlers := make([]Func, 0, len(r.middlewares)+1)
+	handlers = append(append(handlers, r.middlewares...), handler)
+	resp = runHandlers(ctx, request, handlers)
+	if resp == nil {
+		resp = Error(xerror.NotImplemented("no response from handler"))
+	}
+	return resp
+}
+
+// Use registers middlewares which run in order before the handlers of every matched route,
+// including routes registered by HandleWithMeta and HandleWildcard. Middlewares call Next to continue.
+func (r *Router) Use(middlewares ...Func) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// PanicMapper converts a recovered panic value into a response, or returns nil if it doesn't handle the value
+type PanicMapper func(v any) *Response
+
+// OnPanic registers mappers which are consulted in order before responding to a panic with 500
+func (r *Router) OnPanic(mappers ...PanicMapper) {
+	r.panicMappers = append(r.panicMappers, mappers...)
+}
+
+// MapPanic returns a PanicMapper which responds with status if the panic value is an error matching E, e.g.
+// r.OnPanic(lambdahttp.MapPanic[*validator.InvalidValidationError](http.StatusBadRequest))
+func MapPanic[E error](status int) PanicMapper {
+	return func(v any) *Response {
+		err, ok := v.(error)
+		if !ok {
+			return nil
+		}
+		if _, ok = xerror.CauseOf[E](err); !ok {
+			return nil
 		}
-		return resp
+		return errorStatus(status, "%s", err.Error())
 	}
-	return Error(xerror.NotFound("endpoint not found: %s %s", httpInfo.Method, request.RawPath))
 }
 
-func CreateRequestVerifier(pubKey *ecdsa.PublicKey) Func {
+// CreateRequestVerifier verifies ECDSA signatures of requests.
+// If options.IncludeBody is set, the signed message also includes hex encoded sha256 of the plain body,
+// i.e. after base64 decoding and decompression per Content-Encoding. Clients must sign the body before compressing it.
+// As the verifier decompresses by itself, it works the same before or after Decompress.
+func CreateRequestVerifier(pubKey *ecdsa.PublicKey, optFns ...func(options *RequestVerifierOptions)) Func {
+	options := new(RequestVerifierOptions)
+	for _, fn := range optFns {
+		fn(options)
+	}
 	return func(ctx context.Context, request *Request) *Response {
 		if err := xhttp.CheckTimestamp(request.Headers); err != nil {
 			return Error(err)
@@ -90,7 +181,16 @@ func CreateRequestVerifier(pubKey *ecdsa.PublicKey) Func {
 		if err != nil {
 			return Error(err)
 		}
-		hash := getMessageHashForSigning(ctx, request)
+		var hash []byte
+		if options.IncludeBody {
+			body, err := decodeRequestBody(request, options.MaxBodySize)
+			if err != nil {
+				return Error(err)
+			}
+			hash = getMessageHashWithBody(ctx, request, body)
+		} else {
+			hash = getMessageHashForSigning(ctx, request)
+		}
 		if ecdsa.VerifyASN1(pubKey, hash[:], sign) {
 			return Next(ctx, request)
 		}
@@ -98,7 +198,79 @@ func CreateRequestVerifier(pubKey *ecdsa.PublicKey) Func {
 	}
 }
 
+type RequestVerifierOptions struct {
+	// Methods to verify. Defaults to POST, PUT, PATCH and DELETE.
+	Methods []string
+
+	// PathPrefixes restricts verification to requests under these path prefixes if not empty
+	PathPrefixes []string
+
+	// IncludeBody makes the signature cover the plain request body. See CreateRequestVerifier.
+	IncludeBody bool
+
+	// MaxBodySize limits the decompressed body size when IncludeBody is set. Zero means unlimited.
+	MaxBodySize int64
+}
+
+// CreateScopedRequestVerifier verifies signatures like CreateRequestVerifier but only for requests matching
+// Methods and PathPrefixes, others are passed through, e.g. GETs are open while mutations require signing.
+func CreateScopedRequestVerifier(pubKey *ecdsa.PublicKey, optFns ...func(options *RequestVerifierOptions)) Func {
+	options := &RequestVerifierOptions{
+		Methods: []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+	}
+	for _, fn := range optFns {
+		fn(options)
+	}
+
+	verify := CreateRequestVerifier(pubKey, func(o *RequestVerifierOptions) {
+		*o = *options
+	})
+	return func(ctx context.Context, request *Request) *Response {
+		if options.applies(request) {
+			return verify(ctx, request)
+		}
+		return Next(ctx, request)
+	}
+}
+
+func (o *RequestVerifierOptions) applies(request *Request) bool {
+	method := request.RequestContext.HTTP.Method
+	matched := false
+	for _, m := range o.Methods {
+		if strings.EqualFold(m, method) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	if len(o.PathPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range o.PathPrefixes {
+		if strings.HasPrefix(request.RawPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func getMessageHashForSigning(ctx context.Context, req *Request) []byte {
+	hash := md5.Sum(getMessageForSigning(req).Bytes())
+	return hash[:]
+}
+
+// getMessageHashWithBody appends hex encoded sha256 of the plain body to the message
+func getMessageHashWithBody(ctx context.Context, req *Request, body []byte) []byte {
+	buf := getMessageForSigning(req)
+	bodyHash := sha256.Sum256(body)
+	buf.WriteString(hex.EncodeToString(bodyHash[:]))
+	hash := md5.Sum(buf.Bytes())
+	return hash[:]
+}
+
+func getMessageForSigning(req *Request) *bytes.Buffer {
 	httpInfo := req.RequestContext.HTTP
 	var buf bytes.Buffer
 	buf.WriteString(httpInfo.Method)
@@ -106,10 +278,61 @@ func getMessageHashForSigning(ctx context.Context, req *Request) []byte {
 	buf.WriteString(req.RawQueryString)
 	buf.WriteString(xhttp.GetHeader(req.Headers, xhttp.KeyTraceID))
 	buf.WriteString(xhttp.GetHeader(req.Headers, xhttp.KeyTimestamp))
-	hash := md5.Sum(buf.Bytes())
-	return hash[:]
+	return &buf
 }
 
+type nextHandlersKey struct{}
+
 func Next(ctx context.Context, request *Request) *Response {
+	if handlers, ok := ctx.Value(nextHandlersKey{}).([]Func); ok {
+		return runHandlers(ctx, request, handlers)
+	}
 	return router.Next[*Request, *Response](ctx, request)
 }
+
+// runHandlers calls the first of handlers, which reaches the rest via Next
+func runHandlers(ctx context.Context, request *Request, handlers []Func) *Response {
+	if len(handlers) == 0 {
+		return nil
+	}
+	ctx = context.WithValue(ctx, nextHandlersKey{}, handlers[1:])
+	return handlers[0](ctx, request)
+}
+
+// bodyLength returns the length of the body sent to client, i.e. decoded length if body is base64 encoded
+func bodyLength(resp *Response) int {
+	if !resp.IsBase64Encoded {
+		return len(resp.Body)
+	}
+	body := strings.TrimRight(resp.Body, "=")
+	return base64.RawStdEncoding.DecodedLen(len(body))
+}
+
+// truncateUTF8 returns at most n bytes of s without splitting a multibyte character
+func truncateUTF8(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func collapseSlashes(path string) string {
+	if !strings.Contains(path, "//") {
+		return path
+	}
+	var b strings.Builder
+	b.Grow(len(path))
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 && path[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
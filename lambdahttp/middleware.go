@@ -0,0 +1,108 @@
+package lambdahttp
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.olapie.com/log"
+	"code.olapie.com/sugar/v2/xerror"
+	"code.olapie.com/sugar/v2/xhttp"
+)
+
+// RequireContentType rejects requests with body (POST, PUT and PATCH) whose Content-Type isn't one of contentTypes.
+// Parameters like charset are ignored.
+func RequireContentType(contentTypes ...string) Func {
+	allowed := make(map[string]bool, len(contentTypes))
+	for _, t := range contentTypes {
+		allowed[strings.ToLower(t)] = true
+	}
+	return func(ctx context.Context, request *Request) *Response {
+		switch request.RequestContext.HTTP.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			return Next(ctx, request)
+		}
+
+		contentType := xhttp.GetHeader(request.Headers, xhttp.KeyContentType)
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !allowed[mediaType] {
+			return errorStatus(http.StatusUnsupportedMediaType, "unsupported content type: %s", contentType)
+		}
+		return Next(ctx, request)
+	}
+}
+
+// MaxPathLength rejects requests whose path plus query string is longer than n
+func MaxPathLength(n int) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		length := len(request.RawPath)
+		if request.RawQueryString != "" {
+			length += len(request.RawQueryString) + 1
+		}
+		if length > n {
+			log.FromContext(ctx).Warn("Path too long",
+				log.Int("length", length),
+				log.String("source_ip", request.RequestContext.HTTP.SourceIP))
+			return errorStatus(http.StatusRequestURITooLong, "uri is longer than %d", n)
+		}
+		return Next(ctx, request)
+	}
+}
+
+// RetryIdempotent re-invokes the following handlers of GET, HEAD and OPTIONS requests up to attempts times in total
+// while they respond with 5xx, sleeping backoff multiplied by the attempt number in between.
+// Other methods are passed through untouched.
+func RetryIdempotent(attempts int, backoff time.Duration) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		switch request.RequestContext.HTTP.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			return Next(ctx, request)
+		}
+
+		resp := Next(ctx, request)
+		for i := 1; i < attempts && resp != nil && resp.StatusCode >= 500; i++ {
+			log.FromContext(ctx).Warn("Retry", log.Int("attempt", i), log.Int("status_code", resp.StatusCode))
+			select {
+			case <-time.After(backoff * time.Duration(i)):
+			case <-ctx.Done():
+				return resp
+			}
+			resp = Next(ctx, request)
+		}
+		return resp
+	}
+}
+
+func errorStatus(status int, format string, args ...any) *Response {
+	return Error(&xerror.Error{
+		Code:    status,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// maintenanceRetryAfter is Retry-After in seconds of responses in maintenance mode
+const maintenanceRetryAfter = "120"
+
+// MaintenanceMode responds with 503 and Retry-After while enabled returns true, except for requests under exempt path prefixes,
+// e.g. health checks. enabled is called per request so it should be cheap, e.g. an atomic.Bool's Load
+// or func() bool { return os.Getenv("MAINTENANCE") == "1" }
+func MaintenanceMode(enabled func() bool, exempt ...string) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		if !enabled() {
+			return Next(ctx, request)
+		}
+		for _, prefix := range exempt {
+			if strings.HasPrefix(request.RawPath, prefix) {
+				return Next(ctx, request)
+			}
+		}
+		resp := errorStatus(http.StatusServiceUnavailable, "service is under maintenance")
+		resp.Headers["Retry-After"] = maintenanceRetryAfter
+		return resp
+	}
+}
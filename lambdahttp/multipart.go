@@ -0,0 +1,114 @@
+package lambdahttp
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"code.olapie.com/sugar/v2/xerror"
+	"code.olapie.com/sugar/v2/xhttp"
+)
+
+type MultipartOptions struct {
+	// MaxSize limits the decoded body size. Defaults to 10MB, the max payload of API Gateway.
+	MaxSize int64
+
+	// MaxMemory limits bytes of file parts kept in memory, the rest are stored in temporary files.
+	// Defaults to 1MB.
+	MaxMemory int64
+}
+
+// MultipartForm is a parsed multipart/form-data body.
+// RemoveAll should be called to remove temporary files once the form is no longer used.
+type MultipartForm struct {
+	*multipart.Form
+}
+
+// FormValue returns the first value of field name
+func (f *MultipartForm) FormValue(name string) string {
+	if values := f.Value[name]; len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// OpenFile opens the first file part of field name. The caller must close the file.
+func (f *MultipartForm) OpenFile(name string) (multipart.File, *multipart.FileHeader, error) {
+	files := f.File[name]
+	if len(files) == 0 {
+		return nil, nil, xerror.BadRequest("missing file %s", name)
+	}
+	file, err := files[0].Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, files[0], nil
+}
+
+// ParseMultipart parses a multipart/form-data request body.
+// The body is decoded and parsed as a stream without copying it as a whole,
+// and file parts beyond MaxMemory are spilled to temporary files.
+// Bodies larger than MaxSize and forms too large for ReadForm are rejected with 413.
+func ParseMultipart(request *Request, optFns ...func(options *MultipartOptions)) (*MultipartForm, error) {
+	options := &MultipartOptions{
+		MaxSize:   10 << 20,
+		MaxMemory: 1 << 20,
+	}
+	for _, fn := range optFns {
+		fn(options)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(xhttp.GetHeader(request.Headers, xhttp.KeyContentType))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, xerror.BadRequest("content type is not multipart")
+	}
+	boundary := params["boundary"]
+	if boundary == "" {
+		return nil, xerror.BadRequest("missing multipart boundary")
+	}
+
+	var body io.Reader = strings.NewReader(request.Body)
+	if request.IsBase64Encoded {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	// read one extra byte to detect oversized body
+	counter := &countingReader{r: io.LimitReader(body, options.MaxSize+1)}
+	form, err := multipart.NewReader(counter, boundary).ReadForm(options.MaxMemory)
+	// ReadForm may succeed on a body cut off at the limit, e.g. if only the trailing CRLF is cut off
+	if counter.n > options.MaxSize {
+		if form != nil {
+			_ = form.RemoveAll()
+		}
+		return nil, &xerror.Error{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("body exceeds %d bytes", options.MaxSize),
+		}
+	}
+	if err != nil {
+		if errors.Is(err, multipart.ErrMessageTooLarge) {
+			return nil, &xerror.Error{
+				Code:    http.StatusRequestEntityTooLarge,
+				Message: "multipart form is too large",
+			}
+		}
+		return nil, xerror.BadRequest("invalid multipart body: %v", err)
+	}
+	return &MultipartForm{Form: form}, nil
+}
+
+// countingReader counts bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,144 @@
+package lambdahttp_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.olapie.com/awskit/lambdahttp"
+	"code.olapie.com/sugar/v2/xerror"
+	"github.com/stretchr/testify/require"
+)
+
+func newMultipartRequest(t *testing.T, fields map[string]string, files map[string]string) *lambdahttp.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, w.WriteField(name, value))
+	}
+	for name, content := range files {
+		fw, err := w.CreateFormFile(name, name+".txt")
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return &lambdahttp.Request{
+		Headers: map[string]string{"content-type": w.FormDataContentType()},
+		Body:    body.String(),
+	}
+}
+
+func TestParseMultipart(t *testing.T) {
+	t.Run("Plain", func(t *testing.T) {
+		request := newMultipartRequest(t, map[string]string{"name": "tom"}, map[string]string{"avatar": "image"})
+		form, err := lambdahttp.ParseMultipart(request)
+		require.NoError(t, err)
+		defer form.RemoveAll()
+		require.Equal(t, "tom", form.FormValue("name"))
+		require.Empty(t, form.FormValue("missing"))
+
+		file, header, err := form.OpenFile("avatar")
+		require.NoError(t, err)
+		defer file.Close()
+		require.Equal(t, "avatar.txt", header.Filename)
+		content, err := io.ReadAll(file)
+		require.NoError(t, err)
+		require.Equal(t, "image", string(content))
+
+		_, _, err = form.OpenFile("missing")
+		require.Error(t, err)
+	})
+
+	t.Run("Base64", func(t *testing.T) {
+		request := newMultipartRequest(t, map[string]string{"name": "tom"}, nil)
+		request.Body = base64.StdEncoding.EncodeToString([]byte(request.Body))
+		request.IsBase64Encoded = true
+		form, err := lambdahttp.ParseMultipart(request)
+		require.NoError(t, err)
+		defer form.RemoveAll()
+		require.Equal(t, "tom", form.FormValue("name"))
+	})
+
+	t.Run("FileSpilledToDisk", func(t *testing.T) {
+		request := newMultipartRequest(t, nil, map[string]string{"doc": strings.Repeat("a", 4096)})
+		form, err := lambdahttp.ParseMultipart(request, func(options *lambdahttp.MultipartOptions) {
+			options.MaxMemory = 1024
+		})
+		require.NoError(t, err)
+		defer form.RemoveAll()
+		file, _, err := form.OpenFile("doc")
+		require.NoError(t, err)
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		require.NoError(t, err)
+		require.Len(t, content, 4096)
+	})
+}
+
+func TestParseMultipart_Error(t *testing.T) {
+	valid := newMultipartRequest(t, map[string]string{"name": "tom"}, map[string]string{"doc": strings.Repeat("a", 1024)})
+
+	tests := []struct {
+		name    string
+		request *lambdahttp.Request
+		options func(options *lambdahttp.MultipartOptions)
+		status  int
+	}{
+		{
+			name:    "not multipart",
+			request: &lambdahttp.Request{Headers: map[string]string{"content-type": "application/json"}, Body: "{}"},
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "missing boundary",
+			request: &lambdahttp.Request{Headers: map[string]string{"content-type": "multipart/form-data"}, Body: valid.Body},
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "truncated body",
+			request: &lambdahttp.Request{Headers: valid.Headers, Body: valid.Body[:len(valid.Body)/2]},
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "body too large",
+			request: valid,
+			options: func(options *lambdahttp.MultipartOptions) {
+				options.MaxSize = int64(len(valid.Body)) - 1
+			},
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:    "form too large",
+			request: newMultipartRequest(t, map[string]string{"name": strings.Repeat("a", 12<<20)}, nil),
+			options: func(options *lambdahttp.MultipartOptions) {
+				options.MaxSize = 20 << 20
+			},
+			status: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var optFns []func(options *lambdahttp.MultipartOptions)
+			if tt.options != nil {
+				optFns = append(optFns, tt.options)
+			}
+			_, err := lambdahttp.ParseMultipart(tt.request, optFns...)
+			var xerr *xerror.Error
+			require.True(t, errors.As(err, &xerr), err)
+			require.Equal(t, tt.status, xerr.Code)
+		})
+	}
+
+	// a body of exactly MaxSize is accepted
+	form, err := lambdahttp.ParseMultipart(valid, func(options *lambdahttp.MultipartOptions) {
+		options.MaxSize = int64(len(valid.Body))
+	})
+	require.NoError(t, err)
+	require.NoError(t, form.RemoveAll())
+}
@@ -0,0 +1,83 @@
+package lambdahttp
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.olapie.com/sugar/v2/xhttp"
+)
+
+const keyAcceptLanguage = "Accept-Language"
+
+var LanguageKey = NewContextKey[string]("language")
+
+type qualityItem struct {
+	value   string
+	quality float64
+}
+
+// parseQualityList parses headers like Accept-Encoding and Accept-Language into items sorted by quality descending
+func parseQualityList(header string) []*qualityItem {
+	var items []*qualityItem
+	for _, item := range SplitHeaderValues("", header) {
+		value, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		q := 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = f
+			}
+		}
+		items = append(items, &qualityItem{value: value, quality: q})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].quality > items[j].quality
+	})
+	return items
+}
+
+// NegotiateLanguage matches Accept-Language against supported language tags and stores the chosen one in context,
+// which can be read by Language(ctx). fallback is chosen if nothing matches.
+func NegotiateLanguage(fallback string, supported ...string) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		lang := matchLanguage(xhttp.GetHeader(request.Headers, keyAcceptLanguage), supported, fallback)
+		return Next(LanguageKey.Set(ctx, lang), request)
+	}
+}
+
+// Language returns the language chosen by NegotiateLanguage
+func Language(ctx context.Context) string {
+	lang, _ := LanguageKey.Get(ctx)
+	return lang
+}
+
+func matchLanguage(acceptLanguage string, supported []string, fallback string) string {
+	for _, item := range parseQualityList(acceptLanguage) {
+		if item.quality <= 0 {
+			continue
+		}
+		if item.value == "*" {
+			return fallback
+		}
+		// exact match
+		for _, tag := range supported {
+			if strings.EqualFold(tag, item.value) {
+				return tag
+			}
+		}
+		// base language match, e.g. en-US matches en or en-GB
+		base, _, _ := strings.Cut(item.value, "-")
+		for _, tag := range supported {
+			tagBase, _, _ := strings.Cut(tag, "-")
+			if strings.EqualFold(tagBase, base) {
+				return tag
+			}
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,85 @@
+package lambdahttp_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.olapie.com/awskit/lambdahttp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateLanguage(t *testing.T) {
+	r := lambdahttp.NewRouter()
+	r.Use(lambdahttp.NegotiateLanguage("en", "en", "en-GB", "zh-Hans", "fr-CA"))
+	r.HandleWithMeta(http.MethodGet, "/", nil, func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+		return lambdahttp.Text(http.StatusOK, lambdahttp.Language(ctx))
+	})
+
+	tests := []struct {
+		acceptLanguage string
+		language       string
+	}{
+		{"", "en"},
+		{"en-GB", "en-GB"},
+		{"EN-gb", "en-GB"},
+		{"zh-hans", "zh-Hans"},
+		{"en-US", "en"},
+		{"fr", "fr-CA"},
+		{"fr-FR, en;q=0.5", "fr-CA"},
+		{"de, zh-Hans;q=0.8, en-GB;q=0.9", "en-GB"},
+		{"en-GB;q=0, zh-Hans;q=0.1", "zh-Hans"},
+		{"de, ja", "en"},
+		{"*", "en"},
+		{"de;q=1, *;q=0.5", "en"},
+		{"zh-Hans;q=abc, en-GB;q=0.9", "zh-Hans"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			request := newTestRequest(http.MethodGet, "/")
+			if tt.acceptLanguage != "" {
+				request.Headers["accept-language"] = tt.acceptLanguage
+			}
+			resp := r.Handle(context.Background(), request)
+			require.Equal(t, tt.language, resp.Body)
+		})
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	r := lambdahttp.NewRouter()
+	r.Use(lambdahttp.Compress(1))
+	r.HandleWithMeta(http.MethodGet, "/", nil, func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+		return lambdahttp.Text(http.StatusOK, strings.Repeat("hello ", 10))
+	})
+
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"br", "br"},
+		{"gzip, br", "br"},
+		{"GZIP, deflate", "gzip"},
+		{"gzip;q=1, br;q=0.5", "gzip"},
+		{"br;q=0, gzip;q=0.1", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"identity", ""},
+		{"deflate", ""},
+		{"*", "br"},
+		{"*;q=0.5, gzip", "gzip"},
+		{"*;q=0.5, br;q=0", "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			request := newTestRequest(http.MethodGet, "/")
+			if tt.acceptEncoding != "" {
+				request.Headers["accept-encoding"] = tt.acceptEncoding
+			}
+			resp := r.Handle(context.Background(), request)
+			require.Equal(t, tt.encoding, resp.Headers["Content-Encoding"])
+		})
+	}
+}
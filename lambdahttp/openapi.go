@@ -0,0 +1,149 @@
+package lambdahttp
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// OpenAPIDocument is a minimal OpenAPI 3 document, meant to be serialized as JSON and fleshed out by hand
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components *OpenAPIComponents                      `json:"components,omitempty"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIOperation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter   `json:"parameters,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Responses   map[string]any        `json:"responses"`
+
+	// Extensions are route metadata without an OpenAPI counterpart, inlined as x-<key>
+	Extensions map[string]string `json:"-"`
+}
+
+func (o *OpenAPIOperation) MarshalJSON() ([]byte, error) {
+	type plain OpenAPIOperation
+	data, err := json.Marshal((*plain)(o))
+	if err != nil || len(o.Extensions) == 0 {
+		return data, err
+	}
+	var m map[string]any
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range o.Extensions {
+		m[k] = v
+	}
+	return json.Marshal(m)
+}
+
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   map[string]any `json:"schema"`
+}
+
+type OpenAPIComponents struct {
+	SecuritySchemes map[string]any `json:"securitySchemes,omitempty"`
+}
+
+const (
+	openAPIWildcardParam = "wildcard"
+	openAPISecurityName  = "bearerAuth"
+)
+
+// OpenAPI builds an OpenAPI skeleton from Routes. Path parameters like {id} become required path parameters
+// and a trailing wildcard becomes parameter {wildcard}. Metadata summary, description and tags (comma separated)
+// map to the operation's fields, auth=required to bearer security, and other metadata to Extensions.
+func (r *Router) OpenAPI(title, version string) *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: title, Version: version},
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+	for _, rt := range r.Routes() {
+		path, params := openAPIPath(splitPath(rt.Path))
+		op := &OpenAPIOperation{
+			Parameters: params,
+			Responses:  map[string]any{"200": map[string]any{"description": "OK"}},
+		}
+		keys := make([]string, 0, len(rt.Meta))
+		for k := range rt.Meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := rt.Meta[k]
+			switch k {
+			case "summary":
+				op.Summary = v
+			case "description":
+				op.Description = v
+			case "tags":
+				for _, tag := range strings.Split(v, ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						op.Tags = append(op.Tags, tag)
+					}
+				}
+			case "auth":
+				if v == "required" {
+					op.Security = []map[string][]string{{openAPISecurityName: {}}}
+					doc.Components = &OpenAPIComponents{
+						SecuritySchemes: map[string]any{
+							openAPISecurityName: map[string]string{"type": "http", "scheme": "bearer"},
+						},
+					}
+					break
+				}
+				fallthrough
+			default:
+				if op.Extensions == nil {
+					op.Extensions = make(map[string]string)
+				}
+				op.Extensions["x-"+k] = v
+			}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(rt.Method)] = op
+	}
+	return doc
+}
+
+func openAPIPath(segments []string) (string, []*OpenAPIParameter) {
+	var params []*OpenAPIParameter
+	parts := make([]string, len(segments))
+	for i, seg := range segments {
+		name := ""
+		switch {
+		case seg == "*":
+			name = openAPIWildcardParam
+			seg = "{" + name + "}"
+		case isParamSegment(seg):
+			name = seg[1 : len(seg)-1]
+		}
+		if name != "" {
+			params = append(params, &OpenAPIParameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   map[string]any{"type": "string"},
+			})
+		}
+		parts[i] = seg
+	}
+	return "/" + strings.Join(parts, "/"), params
+}
@@ -0,0 +1,80 @@
+package lambdahttp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"code.olapie.com/log"
+	"code.olapie.com/sugar/v2/xhttp"
+)
+
+const (
+	keyOrigin  = "Origin"
+	keyReferer = "Referer"
+)
+
+type OriginOptions struct {
+	// Allowed origins like https://example.com. A leading wildcard like https://*.example.com matches subdomains.
+	Allowed []string
+
+	// AllowMissing passes requests without both Origin and Referer, which are usually sent by non-browser clients
+	AllowMissing bool
+}
+
+// CheckOrigin rejects mutating requests (POST, PUT, PATCH and DELETE) with 403
+// if neither Origin nor Referer is one of allowed origins, which protects browser sessions against CSRF.
+// Requests without both headers are rejected as well.
+func CheckOrigin(allowed ...string) Func {
+	return CheckOriginWithOptions(&OriginOptions{Allowed: allowed})
+}
+
+func CheckOriginWithOptions(options *OriginOptions) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		switch request.RequestContext.HTTP.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			return Next(ctx, request)
+		}
+
+		origin := xhttp.GetHeader(request.Headers, keyOrigin)
+		if origin == "" {
+			if referer := xhttp.GetHeader(request.Headers, keyReferer); referer != "" {
+				if u, err := url.Parse(referer); err == nil && u.Host != "" {
+					origin = u.Scheme + "://" + u.Host
+				} else {
+					origin = "null"
+				}
+			}
+		}
+
+		if origin == "" {
+			if options.AllowMissing {
+				return Next(ctx, request)
+			}
+			return errorStatus(http.StatusForbidden, "missing origin")
+		}
+		if !isOriginAllowed(origin, options.Allowed) {
+			log.FromContext(ctx).Warn("Origin not allowed", log.String("origin", origin))
+			return errorStatus(http.StatusForbidden, "origin %s is not allowed", origin)
+		}
+		return Next(ctx, request)
+	}
+}
+
+func isOriginAllowed(origin string, allowed []string) bool {
+	origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSuffix(a, "/"))
+		if a == origin {
+			return true
+		}
+		// https://*.example.com matches https://a.example.com
+		scheme, host, ok := strings.Cut(a, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package lambdahttp_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"code.olapie.com/awskit/lambdahttp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		missing bool
+		status  int
+	}{
+		{"safe method", http.MethodGet, nil, false, http.StatusOK},
+		{"allowed origin", http.MethodPost, map[string]string{"origin": "https://example.com"}, false, http.StatusOK},
+		{"allowed origin case and slash", http.MethodPut, map[string]string{"Origin": "HTTPS://Example.com/"}, false, http.StatusOK},
+		{"wildcard subdomain", http.MethodPatch, map[string]string{"Origin": "https://a.b.example.org"}, false, http.StatusOK},
+		{"wildcard parent domain", http.MethodPatch, map[string]string{"Origin": "https://example.org"}, false, http.StatusForbidden},
+		{"wildcard scheme", http.MethodPatch, map[string]string{"Origin": "http://a.example.org"}, false, http.StatusForbidden},
+		{"suffix of allowed", http.MethodPost, map[string]string{"Origin": "https://evilexample.com"}, false, http.StatusForbidden},
+		{"prefix of allowed", http.MethodPost, map[string]string{"Origin": "https://example.com.evil.com"}, false, http.StatusForbidden},
+		{"other origin", http.MethodDelete, map[string]string{"Origin": "https://evil.com"}, false, http.StatusForbidden},
+		{"null origin", http.MethodPost, map[string]string{"Origin": "null"}, false, http.StatusForbidden},
+		{"referer", http.MethodPost, map[string]string{"Referer": "https://example.com/page?q=1"}, false, http.StatusOK},
+		{"other referer", http.MethodPost, map[string]string{"Referer": "https://evil.com/example.com"}, false, http.StatusForbidden},
+		{"relative referer", http.MethodPost, map[string]string{"Referer": "/page"}, false, http.StatusForbidden},
+		{"origin over referer", http.MethodPost, map[string]string{
+			"Origin":  "https://evil.com",
+			"Referer": "https://example.com/page",
+		}, false, http.StatusForbidden},
+		{"missing", http.MethodPost, nil, false, http.StatusForbidden},
+		{"allowed missing", http.MethodPost, nil, true, http.StatusOK},
+		{"allowed missing but wrong origin", http.MethodPost, map[string]string{"Origin": "https://evil.com"}, true, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := lambdahttp.NewRouter()
+			r.Use(lambdahttp.CheckOriginWithOptions(&lambdahttp.OriginOptions{
+				Allowed:      []string{"https://example.com", "https://*.example.org"},
+				AllowMissing: tt.missing,
+			}))
+			r.HandleWithMeta(tt.method, "/", nil, func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+				return lambdahttp.Status(http.StatusOK)
+			})
+			request := newTestRequest(tt.method, "/")
+			for k, v := range tt.headers {
+				request.Headers[k] = v
+			}
+			resp := r.Handle(context.Background(), request)
+			require.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package lambdahttp
+
+import "net/http"
+
+// Page is a page of items with an opaque cursor to the next page, which is empty on the last page
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PageOrError responds with a Page of items or the error, e.g.
+// PageOrError(table.QueryPage(ctx, partition, nil, cursor, limit))
+func PageOrError[T any](items []T, nextCursor string, err error) *Response {
+	if err != nil {
+		return Error(err)
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return JSON(http.StatusOK, &Page[T]{
+		Items:      items,
+		NextCursor: nextCursor,
+	})
+}
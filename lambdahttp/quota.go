@@ -0,0 +1,91 @@
+package lambdahttp
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"code.olapie.com/awskit"
+	"code.olapie.com/log"
+)
+
+type QuotaOptions struct {
+	// CacheTTL is how long a prefix size is cached. Defaults to 1 minute.
+	CacheTTL time.Duration
+
+	// Status is the status code when quota is exceeded, e.g. 402. Defaults to 413.
+	Status int
+}
+
+type prefixUsage struct {
+	size      int64
+	expiresAt time.Time
+}
+
+// StorageQuota rejects uploads (POST, PUT and PATCH) which would make the size of the tenant's prefix exceed limit.
+// tenantPrefix returns the prefix of the request's tenant, or empty string to skip the check.
+// Sizes are cached for CacheTTL and increased by accepted uploads in the meantime, to avoid listing on every request.
+// An upload reserves its size before the following handlers run and releases it if they fail.
+func StorageQuota(bucket *awskit.S3Bucket, limit int64, tenantPrefix func(ctx context.Context, request *Request) string,
+	optFns ...func(options *QuotaOptions)) Func {
+	options := &QuotaOptions{
+		CacheTTL: time.Minute,
+		Status:   http.StatusRequestEntityTooLarge,
+	}
+	for _, fn := range optFns {
+		fn(options)
+	}
+
+	var mu sync.Mutex
+	usages := make(map[string]*prefixUsage)
+
+	return func(ctx context.Context, request *Request) *Response {
+		switch request.RequestContext.HTTP.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			return Next(ctx, request)
+		}
+		prefix := tenantPrefix(ctx, request)
+		if prefix == "" {
+			return Next(ctx, request)
+		}
+
+		mu.Lock()
+		usage := usages[prefix]
+		mu.Unlock()
+		if usage == nil || time.Now().After(usage.expiresAt) {
+			size, err := bucket.PrefixSize(ctx, prefix)
+			if err != nil {
+				log.FromContext(ctx).Error("Cannot get prefix size", log.String("prefix", prefix), log.Error(err))
+				return Error(err)
+			}
+			usage = &prefixUsage{size: size, expiresAt: time.Now().Add(options.CacheTTL)}
+			mu.Lock()
+			usages[prefix] = usage
+			mu.Unlock()
+		}
+
+		// reserve incoming under the same lock as the check, so that concurrent uploads can't overshoot limit together
+		incoming := int64(requestBodyLength(request))
+		mu.Lock()
+		if usage.size+incoming > limit {
+			mu.Unlock()
+			return errorStatus(options.Status, "storage quota of %d bytes exceeded", limit)
+		}
+		usage.size += incoming
+		mu.Unlock()
+
+		resp := Next(ctx, request)
+		if resp == nil || resp.StatusCode >= 300 {
+			mu.Lock()
+			usage.size -= incoming
+			mu.Unlock()
+		}
+		return resp
+	}
+}
+
+func requestBodyLength(request *Request) int {
+	return bodyLength(&Response{Body: request.Body, IsBase64Encoded: request.IsBase64Encoded})
+}
@@ -0,0 +1,105 @@
+package lambdahttp_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"code.olapie.com/awskit"
+	"code.olapie.com/awskit/lambdahttp"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/require"
+)
+
+// newListTestBucket lists a single object of size bytes under any prefix
+func newListTestBucket(t *testing.T, size int) *awskit.S3Bucket {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = fmt.Fprintf(w, "<ListBucketResult><KeyCount>1</KeyCount><IsTruncated>false</IsTruncated>"+
+			"<Contents><Key>%sobject</Key><Size>%d</Size></Contents></ListBucketResult>", r.URL.Query().Get("prefix"), size)
+	}))
+	t.Cleanup(srv.Close)
+	client := s3.NewFromConfig(aws.Config{
+		Region:      "us-east-1",
+		Credentials: aws.AnonymousCredentials{},
+		HTTPClient:  srv.Client(),
+	}, func(o *s3.Options) {
+		o.EndpointResolver = s3.EndpointResolverFromURL(srv.URL)
+		o.UsePathStyle = true
+	})
+	return awskit.NewS3Bucket("test", client)
+}
+
+func newQuotaRouter(bucket *awskit.S3Bucket, limit int64, handler lambdahttp.Func) *lambdahttp.Router {
+	r := lambdahttp.NewRouter()
+	r.Use(lambdahttp.StorageQuota(bucket, limit, func(ctx context.Context, request *lambdahttp.Request) string {
+		return "tenant/"
+	}))
+	r.HandleWithMeta(http.MethodPut, "/upload", nil, handler)
+	return r
+}
+
+func newUploadRequest(body string) *lambdahttp.Request {
+	request := newTestRequest(http.MethodPut, "/upload")
+	request.Body = body
+	return request
+}
+
+func TestStorageQuota(t *testing.T) {
+	bucket := newListTestBucket(t, 50)
+	status := http.StatusOK
+	r := newQuotaRouter(bucket, 100, func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+		return lambdahttp.Status(status)
+	})
+
+	tests := []struct {
+		name    string
+		size    int
+		handler int
+		status  int
+	}{
+		{"accepted", 30, http.StatusOK, http.StatusOK},
+		{"exceeded", 30, http.StatusOK, http.StatusRequestEntityTooLarge},
+		{"failed upload is released", 20, http.StatusInternalServerError, http.StatusInternalServerError},
+		{"fits", 20, http.StatusOK, http.StatusOK},
+		{"full", 1, http.StatusOK, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status = tt.handler
+			resp := r.Handle(context.Background(), newUploadRequest(strings.Repeat("a", tt.size)))
+			require.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestStorageQuota_Concurrent(t *testing.T) {
+	bucket := newListTestBucket(t, 50)
+	var accepted int32
+	r := newQuotaRouter(bucket, 100, func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+		atomic.AddInt32(&accepted, 1)
+		time.Sleep(10 * time.Millisecond)
+		return lambdahttp.Status(http.StatusOK)
+	})
+	// load the prefix size first
+	require.Equal(t, http.StatusOK, r.Handle(context.Background(), newUploadRequest("")).StatusCode)
+	atomic.StoreInt32(&accepted, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Handle(context.Background(), newUploadRequest(strings.Repeat("a", 10)))
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(5), atomic.LoadInt32(&accepted))
+}
@@ -0,0 +1,121 @@
+package lambdahttp
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.olapie.com/awskit"
+	"code.olapie.com/sugar/v2/xhttp"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	keyRange        = "Range"
+	keyContentRange = "Content-Range"
+	keyAcceptRanges = "Accept-Ranges"
+	keyETag         = "ETag"
+)
+
+// ServeS3Range responds with the object, honoring the request's Range header.
+// A satisfiable range yields 206 with Content-Range, an unsatisfiable one yields 416,
+// e.g. any range of an empty object. For multi-range requests only the first range is served.
+// A malformed Range header is ignored and the whole object is returned with 200.
+func ServeS3Range(ctx context.Context, request *Request, bucket *awskit.S3Bucket, key string) *Response {
+	header := xhttp.GetHeader(request.Headers, keyRange)
+	start, end, suffix, ok := parseRange(header)
+	if !ok {
+		r, err := bucket.GetRangeInfo(ctx, key, 0, -1)
+		if err != nil {
+			return Error(err)
+		}
+		return rangeResponse(http.StatusOK, r)
+	}
+
+	var r *awskit.ObjectRange
+	var err error
+	if suffix > 0 {
+		r, err = bucket.GetSuffixRangeInfo(ctx, key, suffix)
+	} else {
+		// GetRangeInfo omits Range for bytes=0-, send it anyway so that S3 rejects it for empty objects
+		r, err = bucket.GetRangeInfo(ctx, key, start, end, func(input *s3.GetObjectInput) {
+			input.Range = aws.String(httpRange(start, end))
+		})
+	}
+	if err != nil {
+		if errors.Is(err, awskit.ErrRangeNotSatisfiable) {
+			resp := Status(http.StatusRequestedRangeNotSatisfiable)
+			if head, err := bucket.GetHeadObject(ctx, key); err == nil {
+				resp.Headers[keyContentRange] = fmt.Sprintf("bytes */%d", head.ContentLength)
+			}
+			return resp
+		}
+		return Error(err)
+	}
+	resp := rangeResponse(http.StatusPartialContent, r)
+	resp.Headers[keyContentRange] = fmt.Sprintf("bytes %d-%d/%d", r.Start, r.End, r.Size)
+	return resp
+}
+
+func rangeResponse(status int, r *awskit.ObjectRange) *Response {
+	resp := new(Response)
+	resp.StatusCode = status
+	resp.Headers = make(map[string]string)
+	resp.Headers[keyAcceptRanges] = "bytes"
+	if r.ContentType != "" {
+		resp.Headers[xhttp.KeyContentType] = r.ContentType
+	}
+	if r.ETag != "" {
+		resp.Headers[keyETag] = r.ETag
+	}
+	resp.Body = base64.StdEncoding.EncodeToString(r.Content)
+	resp.IsBase64Encoded = true
+	return resp
+}
+
+func httpRange(start, end int64) string {
+	if end < 0 {
+		return fmt.Sprintf("bytes=%d-", start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", start, end)
+}
+
+// parseRange parses the first range of a Range header.
+// A suffix range like bytes=-500 is returned as suffix=500.
+func parseRange(header string) (start, end, suffix int64, ok bool) {
+	spec := strings.TrimPrefix(strings.TrimSpace(header), "bytes=")
+	if spec == header || spec == "" {
+		return 0, 0, 0, false
+	}
+	spec, _, _ = strings.Cut(spec, ",")
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, 0, false
+	}
+
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, 0, false
+		}
+		return 0, -1, n, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, 0, false
+	}
+	if last == "" {
+		return start, -1, 0, true
+	}
+	end, err = strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, 0, false
+	}
+	return start, end, 0, true
+}
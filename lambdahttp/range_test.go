@@ -0,0 +1,149 @@
+package lambdahttp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"code.olapie.com/awskit"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header string
+		start  int64
+		end    int64
+		suffix int64
+		ok     bool
+	}{
+		{"bytes=0-99", 0, 99, 0, true},
+		{"bytes=100-", 100, -1, 0, true},
+		{"bytes=0-", 0, -1, 0, true},
+		{"bytes=-500", 0, -1, 500, true},
+		{" bytes=5-9 ", 5, 9, 0, true},
+		{"bytes=0-1,5-6", 0, 1, 0, true},
+		{"bytes=-2, 0-1", 0, -1, 2, true},
+		{"", 0, 0, 0, false},
+		{"bytes=", 0, 0, 0, false},
+		{"items=0-1", 0, 0, 0, false},
+		{"0-1", 0, 0, 0, false},
+		{"bytes=5", 0, 0, 0, false},
+		{"bytes=5-2", 0, 0, 0, false},
+		{"bytes=-0", 0, 0, 0, false},
+		{"bytes=-", 0, 0, 0, false},
+		{"bytes=a-b", 0, 0, 0, false},
+		{"bytes=-1-2", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, end, suffix, ok := parseRange(tt.header)
+			require.Equal(t, tt.ok, ok)
+			if ok {
+				require.Equal(t, tt.start, start)
+				require.Equal(t, tt.end, end)
+				require.Equal(t, tt.suffix, suffix)
+			}
+		})
+	}
+}
+
+// newRangeTestBucket serves objects with single ranges like S3, rejecting any range of an empty object
+func newRangeTestBucket(t *testing.T, objects map[string]string) *awskit.S3Bucket {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := objects[strings.TrimPrefix(r.URL.Path, "/test/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		status := http.StatusOK
+		if rng := r.Header.Get(keyRange); rng != "" {
+			start, end, suffix, ok := parseRange(rng)
+			size := int64(len(content))
+			if suffix > size {
+				suffix = size
+			}
+			if suffix > 0 {
+				start, end = size-suffix, size-1
+			} else if end < 0 || end >= size {
+				end = size - 1
+			}
+			if !ok || size == 0 || start >= size {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				_, _ = fmt.Fprint(w, "<Error><Code>InvalidRange</Code></Error>")
+				return
+			}
+			w.Header().Set(keyContentRange, fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+			content = content[start : end+1]
+			status = http.StatusPartialContent
+		}
+		w.Header().Set(keyContentLength, strconv.Itoa(len(content)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			_, _ = fmt.Fprint(w, content)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client := s3.NewFromConfig(aws.Config{
+		Region:      "us-east-1",
+		Credentials: aws.AnonymousCredentials{},
+		HTTPClient:  srv.Client(),
+	}, func(o *s3.Options) {
+		o.EndpointResolver = s3.EndpointResolverFromURL(srv.URL)
+		o.UsePathStyle = true
+	})
+	return awskit.NewS3Bucket("test", client)
+}
+
+func TestServeS3Range(t *testing.T) {
+	bucket := newRangeTestBucket(t, map[string]string{
+		"video": "0123456789",
+		"empty": "",
+	})
+
+	tests := []struct {
+		name         string
+		key          string
+		header       string
+		status       int
+		contentRange string
+		body         string
+	}{
+		{"no range", "video", "", http.StatusOK, "", "0123456789"},
+		{"range", "video", "bytes=2-5", http.StatusPartialContent, "bytes 2-5/10", "2345"},
+		{"open range", "video", "bytes=7-", http.StatusPartialContent, "bytes 7-9/10", "789"},
+		{"whole range", "video", "bytes=0-", http.StatusPartialContent, "bytes 0-9/10", "0123456789"},
+		{"suffix range", "video", "bytes=-3", http.StatusPartialContent, "bytes 7-9/10", "789"},
+		{"long suffix range", "video", "bytes=-30", http.StatusPartialContent, "bytes 0-9/10", "0123456789"},
+		{"multi range", "video", "bytes=0-1,5-6", http.StatusPartialContent, "bytes 0-1/10", "01"},
+		{"unsatisfiable range", "video", "bytes=10-", http.StatusRequestedRangeNotSatisfiable, "bytes */10", ""},
+		{"malformed range", "video", "bytes=5-2", http.StatusOK, "", "0123456789"},
+		{"empty object", "empty", "", http.StatusOK, "", ""},
+		{"empty object with range", "empty", "bytes=0-", http.StatusRequestedRangeNotSatisfiable, "bytes */0", ""},
+		{"empty object with suffix range", "empty", "bytes=-5", http.StatusRequestedRangeNotSatisfiable, "bytes */0", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &Request{Headers: map[string]string{}}
+			if tt.header != "" {
+				request.Headers[keyRange] = tt.header
+			}
+			resp := ServeS3Range(context.Background(), request, bucket, tt.key)
+			require.Equal(t, tt.status, resp.StatusCode)
+			require.Equal(t, tt.contentRange, resp.Headers[keyContentRange])
+			if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+				body, err := base64.StdEncoding.DecodeString(resp.Body)
+				require.NoError(t, err)
+				require.Equal(t, tt.body, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package lambdahttp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type pathParamsKey struct{}
+
+// RouteInfo describes a route registered by Add, HandleWithMeta or HandleWildcard
+type RouteInfo struct {
+	Method string
+	Path   string
+	Meta   map[string]string
+}
+
+type route struct {
+	RouteInfo
+	segments []string
+	handler  Func
+}
+
+// HandleWithMeta registers handler with metadata, e.g. {"auth": "required"}, which can be enumerated by Routes.
+// Path segments like {id} are path parameters and a trailing * matches the remainder of the path.
+// Routes registered on the underlying router take precedence. Middlewares registered by Use run before handler.
+func (r *Router) HandleWithMeta(method, path string, meta map[string]string, handler Func) {
+	segments := splitPath(path)
+	for i, seg := range segments {
+		if seg == "*" && i != len(segments)-1 {
+			panic(fmt.Sprintf("invalid path %s: wildcard must be the last segment", path))
+		}
+	}
+	r.routes = append(r.routes, &route{
+		RouteInfo: RouteInfo{
+			Method: method,
+			Path:   path,
+			Meta:   meta,
+		},
+		segments: segments,
+		handler:  handler,
+	})
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return r.routes[i].moreSpecificThan(r.routes[j])
+	})
+}
+
+// HandleWildcard registers handler for all paths under pattern which must end with a wildcard segment, e.g. /static/*
+// More specific routes take precedence over wildcard routes. The pattern also matches the bare prefix, e.g. /static,
+// with an empty remainder. The remainder of the path is available via PathParam(ctx, "*")
+func (r *Router) HandleWildcard(method, pattern string, handler Func) {
+	if !strings.HasSuffix(pattern, "/*") {
+		panic(fmt.Sprintf("invalid wildcard pattern %s: must end with /*", pattern))
+	}
+	r.HandleWithMeta(method, pattern, nil, handler)
+}
+
+// Add registers handlers on the underlying router like its Add, and records the route so that it's listed by Routes
+func (r *Router) Add(method, path string, handler Func, handlers ...Func) {
+	r.Router.Add(method, path, handler, handlers...)
+	r.endpoints = append(r.endpoints, RouteInfo{Method: method, Path: path})
+}
+
+// Routes returns routes registered by Add, HandleWithMeta or HandleWildcard.
+// Routes of the underlying router come first as they take precedence.
+func (r *Router) Routes() []RouteInfo {
+	infos := make([]RouteInfo, 0, len(r.endpoints)+len(r.routes))
+	infos = append(infos, r.endpoints...)
+	for _, rt := range r.routes {
+		infos = append(infos, rt.RouteInfo)
+	}
+	return infos
+}
+
+func (r *Router) matchRoute(method, path string) (*route, map[string]string) {
+	segments := splitPath(path)
+	for _, rt := range r.routes {
+		if rt.Method != method {
+			continue
+		}
+		if params, ok := rt.match(segments); ok {
+			return rt, params
+		}
+	}
+	return nil, nil
+}
+
+func (rt *route) isWildcard() bool {
+	return rt.segments[len(rt.segments)-1] == "*"
+}
+
+func (rt *route) literals() int {
+	n := 0
+	for _, seg := range rt.segments {
+		if !isParamSegment(seg) && seg != "*" {
+			n++
+		}
+	}
+	return n
+}
+
+func (rt *route) moreSpecificThan(other *route) bool {
+	if rt.isWildcard() != other.isWildcard() {
+		return !rt.isWildcard()
+	}
+	if len(rt.segments) != len(other.segments) {
+		return len(rt.segments) > len(other.segments)
+	}
+	return rt.literals() > other.literals()
+}
+
+func (rt *route) match(segments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for i, seg := range rt.segments {
+		if seg == "*" {
+			params["*"] = strings.Join(segments[i:], "/")
+			return params, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		if isParamSegment(seg) {
+			params[seg[1:len(seg)-1]] = segments[i]
+		} else if seg != segments[i] {
+			return nil, false
+		}
+	}
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func isParamSegment(seg string) bool {
+	return len(seg) > 2 && seg[0] == '{' && seg[len(seg)-1] == '}'
+}
+
+// PathParam returns path parameter name of routes registered by HandleWithMeta or HandleWildcard,
+// e.g. "*" for the remainder matched by a wildcard route
+func PathParam(ctx context.Context, name string) string {
+	params, _ := ctx.Value(pathParamsKey{}).(map[string]string)
+	return params[name]
+}
+
+func withPathParams(ctx context.Context, params map[string]string) context.Context {
+	return context.WithValue(ctx, pathParamsKey{}, params)
+}
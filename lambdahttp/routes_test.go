@@ -0,0 +1,135 @@
+package lambdahttp_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"code.olapie.com/awskit/lambdahttp"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRequest(method, path string) *lambdahttp.Request {
+	request := &lambdahttp.Request{
+		RawPath: path,
+		Headers: make(map[string]string),
+	}
+	request.RequestContext.HTTP.Method = method
+	request.RequestContext.HTTP.Path = path
+	return request
+}
+
+func routeHandler(name string) lambdahttp.Func {
+	return func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+		return lambdahttp.Text(http.StatusOK, name+" "+lambdahttp.PathParam(ctx, "*")+lambdahttp.PathParam(ctx, "id"))
+	}
+}
+
+func TestRouter_HandleWildcard(t *testing.T) {
+	r := lambdahttp.NewRouter()
+	r.HandleWildcard(http.MethodGet, "/static/*", routeHandler("static"))
+	r.HandleWildcard(http.MethodGet, "/static/img/*", routeHandler("img"))
+	r.HandleWithMeta(http.MethodGet, "/static/{id}", nil, routeHandler("file"))
+	r.HandleWithMeta(http.MethodGet, "/static/index.html", nil, routeHandler("index"))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "/static/index.html", http.StatusOK, "index "},
+		{http.MethodGet, "/static/app.js", http.StatusOK, "file app.js"},
+		{http.MethodGet, "/static/img/logo.png", http.StatusOK, "img logo.png"},
+		{http.MethodGet, "/static/img/icons/logo.png", http.StatusOK, "img icons/logo.png"},
+		{http.MethodGet, "/static/js/app.js", http.StatusOK, "static js/app.js"},
+		{http.MethodGet, "/static", http.StatusOK, "static "},
+		{http.MethodGet, "/static/", http.StatusOK, "static "},
+		{http.MethodGet, "/statics", http.StatusNotFound, ""},
+		{http.MethodPost, "/static/js/app.js", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			resp := r.Handle(context.Background(), newTestRequest(tt.method, tt.path))
+			require.Equal(t, tt.status, resp.StatusCode)
+			if tt.status == http.StatusOK {
+				require.Equal(t, tt.body, resp.Body)
+			}
+		})
+	}
+}
+
+func TestRouter_Use(t *testing.T) {
+	r := lambdahttp.NewRouter()
+	var calls []string
+	r.Use(func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+		calls = append(calls, "first")
+		resp := lambdahttp.Next(ctx, request)
+		resp.Headers["X-First"] = "1"
+		return resp
+	}, func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+		calls = append(calls, "second")
+		if request.Headers["Authorization"] == "" {
+			return lambdahttp.Text(http.StatusUnauthorized, "unauthorized")
+		}
+		return lambdahttp.Next(ctx, request)
+	})
+	r.HandleWildcard(http.MethodGet, "/static/*", routeHandler("static"))
+	r.HandleWithMeta(http.MethodGet, "/items/{id}", map[string]string{"auth": "required"}, routeHandler("item"))
+
+	tests := []struct {
+		path   string
+		auth   bool
+		status int
+		body   string
+	}{
+		{"/static/app.js", true, http.StatusOK, "static app.js"},
+		{"/static/app.js", false, http.StatusUnauthorized, "unauthorized"},
+		{"/items/1", true, http.StatusOK, "item 1"},
+		{"/items/1", false, http.StatusUnauthorized, "unauthorized"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			calls = nil
+			request := newTestRequest(http.MethodGet, tt.path)
+			if tt.auth {
+				request.Headers["Authorization"] = "token"
+			}
+			resp := r.Handle(context.Background(), request)
+			require.Equal(t, tt.status, resp.StatusCode)
+			require.Equal(t, tt.body, resp.Body)
+			require.Equal(t, "1", resp.Headers["X-First"])
+			require.Equal(t, []string{"first", "second"}, calls)
+		})
+	}
+
+	// unmatched requests don't run middlewares
+	calls = nil
+	resp := r.Handle(context.Background(), newTestRequest(http.MethodGet, "/missing"))
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Empty(t, calls)
+}
+
+func TestRouter_Routes(t *testing.T) {
+	r := lambdahttp.NewRouter()
+	r.Add(http.MethodGet, "/health", routeHandler("health"))
+	r.HandleWildcard(http.MethodGet, "/static/*", routeHandler("static"))
+	r.HandleWithMeta(http.MethodGet, "/items/{id}", map[string]string{"auth": "required"}, routeHandler("item"))
+	require.Equal(t, []lambdahttp.RouteInfo{
+		{Method: http.MethodGet, Path: "/health"},
+		{Method: http.MethodGet, Path: "/items/{id}", Meta: map[string]string{"auth": "required"}},
+		{Method: http.MethodGet, Path: "/static/*"},
+	}, r.Routes())
+
+	// middlewares see metadata of the matched route
+	r.Use(func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+		if meta, _ := lambdahttp.RouteMetaKey.Get(ctx); meta["auth"] == "required" {
+			return lambdahttp.Text(http.StatusUnauthorized, "unauthorized")
+		}
+		return lambdahttp.Next(ctx, request)
+	})
+	resp := r.Handle(context.Background(), newTestRequest(http.MethodGet, "/items/1"))
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = r.Handle(context.Background(), newTestRequest(http.MethodGet, "/static/app.js"))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,97 @@
+package lambdahttp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math/rand"
+	"strings"
+
+	"code.olapie.com/log"
+)
+
+const redacted = "[REDACTED]"
+
+// DefaultRedactedFields are always redacted by SampleLog
+var DefaultRedactedFields = []string{
+	"password",
+	"secret",
+	"token",
+	"access_token",
+	"refresh_token",
+	"api_key",
+	"authorization",
+}
+
+// SampleLog logs method, path, status and redacted request/response bodies for a fraction (0 to 1) of requests.
+// JSON fields named in redactedFields or DefaultRedactedFields (case-insensitive) are replaced,
+// and non-JSON bodies are never logged.
+func SampleLog(rate float64, redactedFields ...string) Func {
+	fields := make(map[string]bool, len(redactedFields)+len(DefaultRedactedFields))
+	for _, f := range append(redactedFields, DefaultRedactedFields...) {
+		fields[strings.ToLower(f)] = true
+	}
+	return func(ctx context.Context, request *Request) *Response {
+		resp := Next(ctx, request)
+		if rate <= 0 || rand.Float64() >= rate {
+			return resp
+		}
+
+		var status int
+		var respBody string
+		if resp != nil {
+			status = resp.StatusCode
+			respBody = redactBody(resp.Body, resp.IsBase64Encoded, fields)
+		}
+		log.FromContext(ctx).Info("Sample",
+			log.String("method", request.RequestContext.HTTP.Method),
+			log.String("path", request.RawPath),
+			log.String("request_body", redactBody(request.Body, request.IsBase64Encoded, fields)),
+			log.Int("status_code", status),
+			log.String("response_body", respBody),
+		)
+		return resp
+	}
+}
+
+func redactBody(body string, isBase64Encoded bool, fields map[string]bool) string {
+	if body == "" {
+		return ""
+	}
+	data := []byte(body)
+	if isBase64Encoded {
+		var err error
+		data, err = base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return ""
+		}
+	}
+
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return ""
+	}
+	redactValue(v, fields)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func redactValue(v any, fields map[string]bool) {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if fields[strings.ToLower(k)] {
+				val[k] = redacted
+			} else {
+				redactValue(item, fields)
+			}
+		}
+	case []any:
+		for _, item := range val {
+			redactValue(item, fields)
+		}
+	}
+}
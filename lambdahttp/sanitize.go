@@ -0,0 +1,153 @@
+package lambdahttp
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"code.olapie.com/log"
+	"code.olapie.com/sugar/v2/xhttp"
+	"golang.org/x/exp/slices"
+)
+
+const keySetCookie = "Set-Cookie"
+
+// hopByHopHeaders are meaningful for a single connection only and must not be forwarded by the gateway
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// singletonHeaders can't be folded into a list of values
+var singletonHeaders = map[string]bool{
+	"Content-Type":     true,
+	"Content-Length":   true,
+	"Content-Encoding": true,
+	"Content-Range":    true,
+	"Etag":             true,
+	"Last-Modified":    true,
+	"Location":         true,
+	"Retry-After":      true,
+}
+
+// protectedHeaders are never dropped for size, as losing them weakens security or caching correctness
+var protectedHeaders = map[string]bool{
+	"Access-Control-Allow-Credentials": true,
+	"Access-Control-Allow-Origin":      true,
+	"Cache-Control":                    true,
+	"Content-Security-Policy":          true,
+	"Set-Cookie":                       true,
+	"Strict-Transport-Security":        true,
+	"Vary":                             true,
+	"Www-Authenticate":                 true,
+	"X-Content-Type-Options":           true,
+	"X-Frame-Options":                  true,
+}
+
+// SanitizeHeaders cleans up response headers before they reach the gateway, which fails with 5xx on some malformed ones.
+// Headers differing only in case are merged, singleton headers like Content-Type keep one value while others are
+// folded into a comma separated list of distinct values. Set-Cookie can't be folded, so it's moved to Cookies instead.
+// Hop-by-hop headers are removed. If headers are still larger than maxSize bytes, the largest are dropped
+// except for singleton ones, security relevant ones like Vary or Strict-Transport-Security, and the trace id.
+// It should be registered first so that it sees headers set by all other handlers.
+func SanitizeHeaders(maxSize int) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		resp := Next(ctx, request)
+		if resp == nil || len(resp.Headers) == 0 {
+			return resp
+		}
+		logger := log.FromContext(ctx)
+
+		keys := make([]string, 0, len(resp.Headers))
+		for k := range resp.Headers {
+			keys = append(keys, k)
+		}
+		// canonical keys first so that their values win for singleton headers
+		sort.Slice(keys, func(i, j int) bool {
+			ci, cj := keys[i] == http.CanonicalHeaderKey(keys[i]), keys[j] == http.CanonicalHeaderKey(keys[j])
+			if ci != cj {
+				return ci
+			}
+			return keys[i] < keys[j]
+		})
+
+		headers := make(map[string]string, len(resp.Headers))
+		for _, k := range keys {
+			v := resp.Headers[k]
+			ck := http.CanonicalHeaderKey(k)
+			if ck == keySetCookie {
+				if !slices.Contains(resp.Cookies, v) {
+					resp.Cookies = append(resp.Cookies, v)
+				}
+				continue
+			}
+			prev, ok := headers[ck]
+			switch {
+			case !ok:
+				headers[ck] = v
+			case prev == v:
+			case singletonHeaders[ck]:
+				logger.Warn("Drop duplicate header", log.String("key", k), log.String("value", v))
+			default:
+				headers[ck] = mergeHeaderValues(ck, prev, v)
+			}
+		}
+
+		for _, k := range hopByHopHeaders {
+			if _, ok := headers[k]; ok {
+				logger.Warn("Drop hop-by-hop header", log.String("key", k))
+				delete(headers, k)
+			}
+		}
+
+		trimHeaders(ctx, headers, maxSize)
+		resp.Headers = headers
+		return resp
+	}
+}
+
+func mergeHeaderValues(key, a, b string) string {
+	values := SplitHeaderValues(key, a)
+	for _, v := range SplitHeaderValues(key, b) {
+		if !containsFold(values, v) {
+			values = append(values, v)
+		}
+	}
+	return JoinHeaderValues(key, values)
+}
+
+// trimHeaders drops the largest droppable headers until headers fit in maxSize
+func trimHeaders(ctx context.Context, headers map[string]string, maxSize int) {
+	size := 0
+	var droppable []string
+	for k, v := range headers {
+		size += len(k) + len(v) + 4 // ": " and CRLF
+		if !isEssentialHeader(k) {
+			droppable = append(droppable, k)
+		}
+	}
+	if size <= maxSize {
+		return
+	}
+	sort.Slice(droppable, func(i, j int) bool {
+		return len(headers[droppable[i]]) > len(headers[droppable[j]])
+	})
+	for _, k := range droppable {
+		if size <= maxSize {
+			break
+		}
+		size -= len(k) + len(headers[k]) + 4
+		log.FromContext(ctx).Warn("Drop oversized header", log.String("key", k), log.Int("length", len(headers[k])))
+		delete(headers, k)
+	}
+}
+
+func isEssentialHeader(key string) bool {
+	return singletonHeaders[key] || protectedHeaders[key] || key == http.CanonicalHeaderKey(xhttp.KeyTraceID)
+}
@@ -0,0 +1,87 @@
+package lambdahttp_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.olapie.com/awskit/lambdahttp"
+	"code.olapie.com/sugar/v2/xhttp"
+	"github.com/stretchr/testify/require"
+)
+
+func sanitizeHeaders(maxSize int, headers map[string]string) *lambdahttp.Response {
+	r := lambdahttp.NewRouter()
+	r.Use(lambdahttp.SanitizeHeaders(maxSize))
+	r.HandleWithMeta(http.MethodGet, "/", nil, func(ctx context.Context, request *lambdahttp.Request) *lambdahttp.Response {
+		resp := lambdahttp.Status(http.StatusOK)
+		resp.Headers = headers
+		return resp
+	})
+	return r.Handle(context.Background(), newTestRequest(http.MethodGet, "/"))
+}
+
+func TestSanitizeHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    map[string]string
+		cookies []string
+	}{
+		{
+			name:    "canonical key",
+			headers: map[string]string{"content-type": "text/plain"},
+			want:    map[string]string{"Content-Type": "text/plain"},
+		},
+		{
+			name:    "merge list",
+			headers: map[string]string{"Vary": "Origin", "vary": "Accept-Encoding, origin"},
+			want:    map[string]string{"Vary": "Origin, Accept-Encoding"},
+		},
+		{
+			name:    "singleton keeps canonical",
+			headers: map[string]string{"Content-Type": "text/plain", "content-type": "text/html"},
+			want:    map[string]string{"Content-Type": "text/plain"},
+		},
+		{
+			name:    "hop-by-hop",
+			headers: map[string]string{"Connection": "close", "transfer-encoding": "chunked", "Etag": `"1"`},
+			want:    map[string]string{"Etag": `"1"`},
+		},
+		{
+			name:    "set-cookie",
+			headers: map[string]string{"Set-Cookie": "a=1; Expires=Wed, 21 Oct 2015 07:28:00 GMT", "set-cookie": "b=2"},
+			want:    map[string]string{},
+			cookies: []string{"a=1; Expires=Wed, 21 Oct 2015 07:28:00 GMT", "b=2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := sanitizeHeaders(1024, tt.headers)
+			delete(resp.Headers, http.CanonicalHeaderKey(xhttp.KeyTraceID))
+			require.Equal(t, tt.want, resp.Headers)
+			require.Equal(t, tt.cookies, resp.Cookies)
+		})
+	}
+}
+
+func TestSanitizeHeaders_Trim(t *testing.T) {
+	large := strings.Repeat("a", 200)
+	resp := sanitizeHeaders(600, map[string]string{
+		"Content-Type":              "text/plain",
+		"Vary":                      "Origin",
+		"Strict-Transport-Security": "max-age=" + large,
+		"Content-Security-Policy":   "default-src " + large,
+		"X-Large":                   large + large,
+		"X-Medium":                  large,
+		"X-Small":                   "small",
+	})
+	require.Equal(t, "text/plain", resp.Headers["Content-Type"])
+	require.Equal(t, "Origin", resp.Headers["Vary"])
+	require.NotEmpty(t, resp.Headers["Strict-Transport-Security"])
+	require.NotEmpty(t, resp.Headers["Content-Security-Policy"])
+	require.NotContains(t, resp.Headers, "X-Large")
+	require.NotContains(t, resp.Headers, "X-Medium")
+	require.Equal(t, "small", resp.Headers["X-Small"])
+}
@@ -0,0 +1,83 @@
+package lambdahttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+
+	"code.olapie.com/log"
+	"code.olapie.com/sugar/v2/xcontext"
+	"code.olapie.com/sugar/v2/xhttp"
+)
+
+const KeyResponseSignature = "X-Response-Signature"
+
+type ResponseSignerOptions struct {
+	// Headers are included in the signature in order. Defaults to trace id.
+	Headers []string
+
+	// Canonicalize builds the message to be hashed and signed.
+	// Defaults to status code, values of Headers and hex encoded sha256 of body, concatenated.
+	Canonicalize func(resp *Response, headers []string) []byte
+}
+
+// CreateResponseSigner signs responses with privKey so that clients can verify their authenticity.
+// The base64 encoded ASN.1 signature over sha256 of the canonical message is set in header X-Response-Signature.
+// It's the counterpart of CreateRequestVerifier and should be registered before middlewares which modify responses.
+func CreateResponseSigner(privKey *ecdsa.PrivateKey, optFns ...func(options *ResponseSignerOptions)) Func {
+	options := &ResponseSignerOptions{
+		Headers:      []string{xhttp.KeyTraceID},
+		Canonicalize: canonicalizeResponse,
+	}
+	for _, fn := range optFns {
+		fn(options)
+	}
+
+	return func(ctx context.Context, request *Request) *Response {
+		resp := Next(ctx, request)
+		if resp == nil {
+			return resp
+		}
+		if resp.Headers == nil {
+			resp.Headers = make(map[string]string)
+		}
+		// router sets trace id after all handlers return, set it in advance so that it can be signed
+		xhttp.SetTraceID(resp.Headers, xcontext.GetTraceID(ctx))
+
+		sign, err := signMessage(privKey, options.Canonicalize(resp, options.Headers))
+		if err != nil {
+			log.FromContext(ctx).Error("sign response", log.Error(err))
+			return Error(err)
+		}
+		resp.Headers[KeyResponseSignature] = base64.StdEncoding.EncodeToString(sign)
+		return resp
+	}
+}
+
+// signMessage returns the ASN.1 signature over sha256 of msg
+func signMessage(privKey *ecdsa.PrivateKey, msg []byte) ([]byte, error) {
+	hash := sha256.Sum256(msg)
+	return ecdsa.SignASN1(rand.Reader, privKey, hash[:])
+}
+
+// verifyMessage is the counterpart of signMessage
+func verifyMessage(pubKey *ecdsa.PublicKey, msg, sign []byte) bool {
+	hash := sha256.Sum256(msg)
+	return ecdsa.VerifyASN1(pubKey, hash[:], sign)
+}
+
+func canonicalizeResponse(resp *Response, headers []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(strconv.Itoa(resp.StatusCode))
+	for _, h := range headers {
+		buf.WriteString(xhttp.GetHeader(resp.Headers, h))
+	}
+	bodyHash := sha256.Sum256([]byte(resp.Body))
+	buf.WriteString(hex.EncodeToString(bodyHash[:]))
+	return buf.Bytes()
+}
@@ -0,0 +1,130 @@
+package lambdahttp
+
+import (
+	"context"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"code.olapie.com/awskit"
+	"code.olapie.com/sugar/v2/xerror"
+	"code.olapie.com/sugar/v2/xhttp"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+type SPAOptions struct {
+	// StripPrefix is removed from request paths before they're mapped to object keys, e.g. /app
+	StripPrefix string
+
+	// Index is the object served for / and client-side routes. Defaults to index.html.
+	Index string
+
+	// Fallback makes paths without a file extension which don't exist serve Index, so that the app can route them.
+	// Defaults to true.
+	Fallback bool
+
+	// AssetCacheControl is set on assets other than Index, which are expected to have hashed names.
+	// Defaults to public, max-age=31536000, immutable
+	AssetCacheControl string
+
+	// IndexCacheControl is set on Index so that new deployments are picked up. Defaults to no-cache.
+	IndexCacheControl string
+}
+
+// ServeSPA serves a single-page app's files under prefix in bucket, e.g. GET /assets/app.3f2a.js reads prefix+assets/app.3f2a.js.
+// Content-Type is taken from the object, or from the file extension if the object doesn't have a specific one.
+func ServeSPA(bucket *awskit.S3Bucket, prefix string, optFns ...func(options *SPAOptions)) Func {
+	options := &SPAOptions{
+		Index:             "index.html",
+		Fallback:          true,
+		AssetCacheControl: "public, max-age=31536000, immutable",
+		IndexCacheControl: "no-cache",
+	}
+	for _, fn := range optFns {
+		fn(options)
+	}
+
+	return func(ctx context.Context, request *Request) *Response {
+		switch request.RequestContext.HTTP.Method {
+		case http.MethodGet, http.MethodHead:
+		default:
+			return errorStatus(http.StatusMethodNotAllowed, "method %s not allowed", request.RequestContext.HTTP.Method)
+		}
+
+		name := strings.TrimPrefix(request.RawPath, options.StripPrefix)
+		name = strings.TrimPrefix(path.Clean("/"+name), "/")
+		if name == "" {
+			name = options.Index
+		}
+
+		head := request.RequestContext.HTTP.Method == http.MethodHead
+		resp := serveSPAFile(ctx, bucket, prefix, name, head, options)
+		if resp.StatusCode == http.StatusNotFound && options.Fallback && name != options.Index && path.Ext(name) == "" {
+			resp = serveSPAFile(ctx, bucket, prefix, options.Index, head, options)
+		}
+		return resp
+	}
+}
+
+// serveSPAFile reads the object, or only its headers if head is true
+func serveSPAFile(ctx context.Context, bucket *awskit.S3Bucket, prefix, name string, head bool, options *SPAOptions) *Response {
+	var (
+		contentType, etag string
+		content           []byte
+		size              int64
+	)
+	if head {
+		output, err := bucket.GetHeadObject(ctx, prefix+name)
+		if err != nil {
+			return spaError(name, err)
+		}
+		contentType = aws.ToString(output.ContentType)
+		etag = aws.ToString(output.ETag)
+		size = output.ContentLength
+	} else {
+		obj, err := bucket.GetWithMetadata(ctx, prefix+name)
+		if err != nil {
+			return spaError(name, err)
+		}
+		contentType = obj.ContentType
+		etag = obj.ETag
+		content = obj.Content
+	}
+
+	resp := new(Response)
+	resp.StatusCode = http.StatusOK
+	resp.Headers = make(map[string]string)
+	if contentType == "" || contentType == "application/octet-stream" || contentType == "binary/octet-stream" {
+		if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+			contentType = t
+		}
+	}
+	if contentType != "" {
+		resp.Headers[xhttp.KeyContentType] = contentType
+	}
+	if etag != "" {
+		resp.Headers[keyETag] = etag
+	}
+	if name == options.Index {
+		resp.Headers[keyCacheControl] = options.IndexCacheControl
+	} else {
+		resp.Headers[keyCacheControl] = options.AssetCacheControl
+	}
+	if head {
+		resp.Headers[keyContentLength] = strconv.FormatInt(size, 10)
+		return resp
+	}
+	resp.Body = base64.StdEncoding.EncodeToString(content)
+	resp.IsBase64Encoded = true
+	return resp
+}
+
+func spaError(name string, err error) *Response {
+	if xerror.IsNotExist(err) {
+		return Error(xerror.NotFound("%s not found", name))
+	}
+	return Error(err)
+}
@@ -0,0 +1,69 @@
+package lambdahttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"code.olapie.com/awskit"
+	"code.olapie.com/sugar/v2/xhttp"
+)
+
+const contentTypeEventStream = "text/event-stream"
+
+type flusher interface {
+	Flush() error
+}
+
+// WriteListEvents lists objects under prefix and writes each page to w as a server-sent event named "page",
+// followed by an event named "end". w is flushed after each page if it has a Flush() error method,
+// so that it can feed a streaming response body, e.g. the writer side of an io.Pipe.
+// Listing stops when ctx is cancelled, e.g. the client disconnects.
+func WriteListEvents(ctx context.Context, w io.Writer, bucket *awskit.S3Bucket, prefix string) error {
+	f, _ := w.(flusher)
+	err := bucket.ListPages(ctx, prefix, func(objects []*awskit.ObjectInfo) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(objects)
+		if err != nil {
+			return fmt.Errorf("json.Marshal: %w", err)
+		}
+		if err = writeEvent(w, "page", data); err != nil {
+			return err
+		}
+		if f != nil {
+			return f.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		data, _ := json.Marshal(err.Error())
+		_ = writeEvent(w, "error", data)
+		return err
+	}
+	return writeEvent(w, "end", []byte("{}"))
+}
+
+// ListEvents responds with all pages as server-sent events in a single body.
+// API Gateway buffers responses, use WriteListEvents with Lambda response streaming for incremental delivery.
+func ListEvents(ctx context.Context, bucket *awskit.S3Bucket, prefix string) *Response {
+	var buf bytes.Buffer
+	_ = WriteListEvents(ctx, &buf, bucket, prefix)
+	resp := new(Response)
+	resp.StatusCode = http.StatusOK
+	resp.Headers = map[string]string{
+		xhttp.KeyContentType: contentTypeEventStream,
+		keyCacheControl:      "no-cache",
+	}
+	resp.Body = buf.String()
+	return resp
+}
+
+func writeEvent(w io.Writer, event string, data []byte) error {
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
@@ -0,0 +1,12 @@
+package lambdahttp
+
+// Stage returns the API Gateway stage of request, e.g. $default or prod
+func Stage(request *Request) string {
+	return request.RequestContext.Stage
+}
+
+// StageVar returns stage variable name of request, which can vary config across stages without redeploying
+func StageVar(request *Request, name string) (string, bool) {
+	v, ok := request.StageVariables[name]
+	return v, ok
+}
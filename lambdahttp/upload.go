@@ -0,0 +1,71 @@
+package lambdahttp
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"code.olapie.com/awskit"
+	"code.olapie.com/sugar/v2/xerror"
+	"code.olapie.com/sugar/v2/xhttp"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type UploadOptions struct {
+	// MaxSize limits the decoded body size. Zero means unlimited.
+	MaxSize int64
+
+	// ContentTypes restricts the declared Content-Type of the request if not empty, e.g. image/png
+	ContentTypes []string
+}
+
+// ProxyUpload streams request's (base64 decoded) body into bucket under key and returns the ETag.
+// The object's content type is the request's declared Content-Type.
+func ProxyUpload(ctx context.Context, request *Request, bucket *awskit.S3Bucket, key string, optFns ...func(options *UploadOptions)) (string, error) {
+	options := new(UploadOptions)
+	for _, fn := range optFns {
+		fn(options)
+	}
+
+	contentType := xhttp.GetHeader(request.Headers, xhttp.KeyContentType)
+	if len(options.ContentTypes) != 0 {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !containsFold(options.ContentTypes, mediaType) {
+			return "", &xerror.Error{
+				Code:    http.StatusUnsupportedMediaType,
+				Message: "unsupported content type " + contentType,
+			}
+		}
+	}
+
+	size := int64(requestBodyLength(request))
+	if options.MaxSize > 0 && size > options.MaxSize {
+		return "", &xerror.Error{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: "body is too large",
+		}
+	}
+
+	var body io.Reader = strings.NewReader(request.Body)
+	if request.IsBase64Encoded {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	return bucket.PutReader(ctx, key, body, size, nil, func(input *s3.PutObjectInput) {
+		if contentType != "" {
+			input.ContentType = aws.String(contentType)
+		}
+	})
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
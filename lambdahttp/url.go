@@ -0,0 +1,58 @@
+package lambdahttp
+
+import (
+	"strings"
+
+	"code.olapie.com/sugar/v2/xhttp"
+)
+
+const (
+	keyForwardedProto = "X-Forwarded-Proto"
+	keyForwardedHost  = "X-Forwarded-Host"
+	keyForwardedPort  = "X-Forwarded-Port"
+	keyHost           = "Host"
+)
+
+// FullURL returns the absolute URL of request, e.g. https://api.example.com/v1/items?page=2.
+// X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Port take precedence over the Host header and domain name.
+func FullURL(request *Request) string {
+	scheme := firstHeaderValue(request.Headers, keyForwardedProto)
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	host := firstHeaderValue(request.Headers, keyForwardedHost)
+	if host == "" {
+		host = xhttp.GetHeader(request.Headers, keyHost)
+	}
+	if host == "" {
+		host = request.RequestContext.DomainName
+	}
+	port := firstHeaderValue(request.Headers, keyForwardedPort)
+	if port != "" && !strings.Contains(host, ":") &&
+		!(scheme == "https" && port == "443") && !(scheme == "http" && port == "80") {
+		host += ":" + port
+	}
+
+	var b strings.Builder
+	b.WriteString(scheme)
+	b.WriteString("://")
+	b.WriteString(host)
+	if !strings.HasPrefix(request.RawPath, "/") {
+		b.WriteByte('/')
+	}
+	b.WriteString(request.RawPath)
+	if request.RawQueryString != "" {
+		b.WriteByte('?')
+		b.WriteString(request.RawQueryString)
+	}
+	return b.String()
+}
+
+// firstHeaderValue returns the first value of a header which proxies may have appended to
+func firstHeaderValue(headers map[string]string, key string) string {
+	if values := HeaderValues(headers, key); len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package lambdahttp
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"code.olapie.com/log"
+	"code.olapie.com/sugar/v2/xhttp"
+	"code.olapie.com/sugar/v2/xjson"
+)
+
+const KeyClientVersion = "X-Client-Version"
+
+// VersionTransformer downgrades JSON response bodies for clients whose version matches Applies.
+// Transform receives the body decoded into any, e.g. map[string]any, and returns the reshaped body.
+type VersionTransformer struct {
+	Applies   func(version string) bool
+	Transform func(body any) (any, error)
+}
+
+// TransformForClientVersion rewrites JSON responses with transformers matching X-Client-Version, in order,
+// so that handlers produce the latest shape only. Requests without X-Client-Version aren't transformed.
+// It should be registered after Compress, which can't be undone, and works with any JSON helper, e.g. JSON200 or Envelope.
+// A transformer failure is logged and the response is returned as is.
+func TransformForClientVersion(transformers ...*VersionTransformer) Func {
+	return func(ctx context.Context, request *Request) *Response {
+		resp := Next(ctx, request)
+		version := xhttp.GetHeader(request.Headers, KeyClientVersion)
+		if resp == nil || version == "" || resp.IsBase64Encoded ||
+			!strings.Contains(xhttp.GetHeader(resp.Headers, xhttp.KeyContentType), "json") {
+			return resp
+		}
+
+		var body any
+		transformed := false
+		for _, t := range transformers {
+			if !t.Applies(version) {
+				continue
+			}
+			if !transformed {
+				if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+					log.FromContext(ctx).Error("decode response", log.Error(err))
+					return resp
+				}
+				transformed = true
+			}
+			var err error
+			body, err = t.Transform(body)
+			if err != nil {
+				log.FromContext(ctx).Error("transform response", log.String("version", version), log.Error(err))
+				return resp
+			}
+		}
+		if transformed {
+			resp.Body = xjson.ToString(body)
+		}
+		return resp
+	}
+}
+
+// VersionBelow returns a predicate for VersionTransformer.Applies which matches versions lower than v,
+// compared by dot separated numbers with an optional v prefix, e.g. 1.9.2 < 1.10
+func VersionBelow(v string) func(version string) bool {
+	return func(version string) bool {
+		return compareVersions(version, v) < 0
+	}
+}
+
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package lambdahttp
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+const KeyXRayTraceID = "X-Amzn-Trace-Id"
+
+var xrayRootRegexp = regexp.MustCompile(`^1-[0-9a-fA-F]{8}-[0-9a-fA-F]{24}$`)
+
+type xrayTraceKey struct{}
+
+type xrayTrace struct {
+	root   string
+	header string
+}
+
+// ParseXRayTraceHeader extracts the root trace id from a X-Amzn-Trace-Id header value,
+// e.g. Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1
+func ParseXRayTraceHeader(header string) (string, bool) {
+	for _, field := range strings.Split(header, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if ok && k == "Root" && xrayRootRegexp.MatchString(v) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// WithXRayTraceHeader parses header and stores the trace in ctx. Invalid headers are ignored.
+func WithXRayTraceHeader(ctx context.Context, header string) context.Context {
+	root, ok := ParseXRayTraceHeader(header)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, xrayTraceKey{}, &xrayTrace{
+		root:   root,
+		header: header,
+	})
+}
+
+// XRayTraceID returns the X-Ray root trace id, or empty string if the request carries no valid trace header
+func XRayTraceID(ctx context.Context) string {
+	if t, ok := ctx.Value(xrayTraceKey{}).(*xrayTrace); ok {
+		return t.root
+	}
+	return ""
+}
+
+// SetXRayTraceHeader propagates the trace in ctx to a downstream http request header
+func SetXRayTraceHeader(ctx context.Context, h http.Header) {
+	if t, ok := ctx.Value(xrayTraceKey{}).(*xrayTrace); ok {
+		h.Set(KeyXRayTraceID, t.header)
+	}
+}
+
+// AddXRayTraceHeader is an aws client API option which propagates the trace in ctx to aws requests, e.g.
+// s3.NewFromConfig(cfg, func(o *s3.Options) { o.APIOptions = append(o.APIOptions, lambdahttp.AddXRayTraceHeader) })
+func AddXRayTraceHeader(stack *middleware.Stack) error {
+	return stack.Build.Add(middleware.BuildMiddlewareFunc("XRayTraceHeader", func(
+		ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler,
+	) (middleware.BuildOutput, middleware.Metadata, error) {
+		if req, ok := in.Request.(*smithyhttp.Request); ok {
+			SetXRayTraceHeader(ctx, req.Header)
+		}
+		return next.HandleBuild(ctx, in)
+	}), middleware.After)
+}
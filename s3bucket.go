@@ -1,23 +1,32 @@
 package awskit
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"code.olapie.com/sugar/v2/xerror"
-	"code.olapie.com/sugar/v2/xmap"
 	"code.olapie.com/sugar/v2/xruntime"
-	"code.olapie.com/sugar/v2/xslice"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	awssigner "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/smithy-go"
+	"golang.org/x/exp/slices"
 )
 
 const (
@@ -27,95 +36,561 @@ const (
 var s3ErrorNotFound = &types.NotFound{}
 var _ error = s3ErrorNotFound
 
+// languageTagRegexp loosely matches BCP 47 language tags
+var languageTagRegexp = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// ErrObjectTooLarge is returned by Get and other reading methods if object size exceeds S3Bucket.MaxObjectSize
+var ErrObjectTooLarge = errors.New("object too large")
+
+// ErrDeleteUnconfirmed is returned by Delete and BatchDelete if objects were deleted
+// but their absence wasn't confirmed before the waiter gave up. It's usually safe to ignore.
+var ErrDeleteUnconfirmed = errors.New("delete unconfirmed")
+
+// ErrChecksumMismatch is returned by PutVerified if the stored object doesn't match the uploaded content
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 type S3Bucket struct {
 	bucket             string
 	client             *s3.Client
 	presignClient      *s3.PresignClient
 	objExistsWaiter    *s3.ObjectExistsWaiter
 	objNotExistsWaiter *s3.ObjectNotExistsWaiter
+	limiter            *concurrencyLimiter
+	retryer            aws.Retryer
 
-	ACL          types.ObjectCannedACL
+	ACL types.ObjectCannedACL
+
+	// CacheControl is set on uploaded objects. Empty value means no Cache-Control header,
+	// which is preferred for private objects as the default is public.
 	CacheControl string
+
+	// ContentLanguage is the default Content-Language of uploaded objects, e.g. en-US
+	ContentLanguage string
+
+	// ServerSideEncryption is requested on uploaded and copied objects if not empty, e.g. aws:kms.
+	// Empty value leaves it to the bucket's default encryption.
+	ServerSideEncryption types.ServerSideEncryption
+
+	// KMSKeyID is the KMS key of ServerSideEncryption aws:kms. Empty value means the AWS managed key aws/s3.
+	KMSKeyID string
+
+	// MaxObjectSize limits how many bytes Get, GetRange, GetValue, Increment and GetChunked read.
+	// GetStream and GetResponse leave it to the caller. Zero means unlimited.
+	MaxObjectSize int64
+
+	// RetryableFunc overrides the client retryer's classification of retryable errors if not nil,
+	// e.g. never retry on a tight latency budget or retry on backend-specific error codes
+	RetryableFunc RetryableFunc
+
+	// Observer is called after each S3 operation if not nil
+	Observer S3Observer
+
+	// PresignSkew backdates presigned URLs' signing time to tolerate clients whose clocks are behind.
+	// A URL is valid from PresignSkew before presigning until ttl after, capped at 7 days.
+	PresignSkew time.Duration
+
+	// AuditSink receives records of changes made by UpdateMetadata, PutTags and SetACL if not nil, except in DryRun mode
+	AuditSink AuditSink
+
+	// AuditBefore makes PutTags and SetACL read the previous state for audit records, which costs an extra request
+	AuditBefore bool
+
+	// KeyCase normalizes object keys and prefixes passed to all operations. Defaults to KeyCaseAsIs.
+	// Objects whose keys aren't normalized become unreachable once it's enabled, e.g. Foo.txt can't be read
+	// with KeyCaseLower as the key is turned into foo.txt, so existing mixed-case objects should be renamed first.
+	KeyCase KeyCase
+
+	// MaxConcurrency bounds in-flight S3 operations of the bucket, including those started by batch helpers,
+	// to avoid self-inflicted throttling under burst load. Operations block until a slot is free or ctx is done.
+	// Zero means unlimited. It must be set before the first operation and is shared by copies of the bucket.
+	MaxConcurrency int
+
+	// DeleteWaitTimeout is how long Delete and BatchDelete wait for deletion to be confirmed. Defaults to 5 seconds.
+	// Zero skips waiting for callers which don't need read-after-delete consistency.
+	DeleteWaitTimeout time.Duration
+
+	// DryRun logs mutating operations, e.g. Put, Delete, BatchDelete and Copy, instead of sending them to S3,
+	// and makes them succeed with zero results, to preview a destructive migration against a production bucket.
+	// Reads like Get, Exists and List still hit S3, so they don't reflect the skipped mutations.
+	DryRun bool
+
+	// ExistsViaList makes Exists list with the key as prefix instead of HEAD the object,
+	// for environments where IAM allows s3:ListBucket but not s3:GetObject which HEAD requires.
+	// LIST requests cost more than HEAD, and like HEAD they're strongly consistent.
+	ExistsViaList bool
+
+	// AllowedStorageClasses restricts storage classes accepted by PutWithOptions if not empty,
+	// e.g. exclude ONEZONE_IA for buckets whose replication setup doesn't support it
+	AllowedStorageClasses []types.StorageClass
 }
 
-func NewS3Bucket(bucket string, c *s3.Client) *S3Bucket {
+// KeyCase is the case normalization of object keys
+type KeyCase int
+
+const (
+	KeyCaseAsIs KeyCase = iota
+	KeyCaseLower
+	KeyCaseUpper
+)
+
+type PutOptions struct {
+	Metadata     map[string]string
+	StorageClass types.StorageClass
+
+	// ContentLanguage overrides S3Bucket.ContentLanguage
+	ContentLanguage string
+
+	// ContentDisposition e.g. attachment; filename="report.pdf"
+	ContentDisposition string
+
+	// ContentType overrides the type sniffed from content, e.g. image/svg+xml which is sniffed as text/plain
+	ContentType string
+
+	// ContentEncoding e.g. gzip for precompressed content
+	ContentEncoding string
+
+	// CacheControl overrides S3Bucket.CacheControl
+	CacheControl string
+
+	// Tags are attached to the object, e.g. for lifecycle rules and cost allocation. See PutTags for limits.
+	Tags map[string]string
+}
+
+// NewS3Bucket creates an S3Bucket. bucket can be either a bucket name or an access point ARN (including multi-region access point).
+// bucket isn't validated, use NewS3BucketE to reject malformed access point ARNs before the first request.
+func NewS3Bucket(bucket string, c *s3.Client, options ...S3BucketOption) *S3Bucket {
 	s := &S3Bucket{
 		bucket:        bucket,
 		client:        c,
 		presignClient: s3.NewPresignClient(c),
+		limiter:       &concurrencyLimiter{},
 
-		ACL:          types.ObjectCannedACLPrivate,
-		CacheControl: cacheControl,
+		ACL:               types.ObjectCannedACLPrivate,
+		CacheControl:      cacheControl,
+		DeleteWaitTimeout: 5 * time.Second,
 	}
 	s.objExistsWaiter = s3.NewObjectExistsWaiter(s.client)
 	s.objNotExistsWaiter = s3.NewObjectNotExistsWaiter(s.client)
+	for _, fn := range options {
+		fn(s)
+	}
+	return s
+}
+
+// NewS3BucketE is like NewS3Bucket but returns an error if bucket looks like an ARN but isn't a valid S3 access point ARN
+func NewS3BucketE(bucket string, c *s3.Client, options ...S3BucketOption) (*S3Bucket, error) {
+	if strings.HasPrefix(bucket, "arn:") {
+		if err := ValidateS3AccessPointARN(bucket); err != nil {
+			return nil, err
+		}
+	}
+	return NewS3Bucket(bucket, c, options...), nil
+}
+
+// MustNewS3Bucket is like NewS3BucketE but panics on error, e.g. for buckets configured at init time
+func MustNewS3Bucket(bucket string, c *s3.Client, options ...S3BucketOption) *S3Bucket {
+	s, err := NewS3BucketE(bucket, c, options...)
+	if err != nil {
+		panic(err)
+	}
 	return s
 }
 
 func NewS3BucketFromConfig(bucket string, cfg aws.Config, options ...func(*s3.Options)) *S3Bucket {
-	return NewS3Bucket(bucket, s3.NewFromConfig(cfg, options...))
+	return NewS3Bucket(bucket, s3.NewFromConfig(cfg, s3OptionsForBucket(bucket, options)...))
+}
+
+// NewS3BucketFromConfigE is like NewS3BucketFromConfig but validates bucket like NewS3BucketE
+func NewS3BucketFromConfigE(bucket string, cfg aws.Config, options ...func(*s3.Options)) (*S3Bucket, error) {
+	return NewS3BucketE(bucket, s3.NewFromConfig(cfg, s3OptionsForBucket(bucket, options)...))
+}
+
+func s3OptionsForBucket(bucket string, options []func(*s3.Options)) []func(*s3.Options) {
+	if arn.IsARN(bucket) {
+		// let the client route requests to the access point's region
+		options = append([]func(*s3.Options){func(o *s3.Options) {
+			o.UseARNRegion = true
+		}}, options...)
+	}
+	return options
+}
+
+// ValidateS3AccessPointARN checks if s is an access point ARN like arn:aws:s3:us-west-2:123456789012:accesspoint/name
+// or a multi-region access point ARN like arn:aws:s3::123456789012:accesspoint/alias.mrap
+func ValidateS3AccessPointARN(s string) error {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid access point arn %s: %w", s, err)
+	}
+	if a.Service != "s3" {
+		return fmt.Errorf("invalid access point arn %s: service %s is not s3", s, a.Service)
+	}
+	if a.AccountID == "" {
+		return fmt.Errorf("invalid access point arn %s: missing account id", s)
+	}
+	name := strings.TrimPrefix(a.Resource, "accesspoint/")
+	if name == a.Resource || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid access point arn %s: resource must be accesspoint/{name}", s)
+	}
+	if a.Region == "" && !strings.HasSuffix(name, ".mrap") {
+		return fmt.Errorf("invalid access point arn %s: missing region", s)
+	}
+	return nil
 }
 
 func (s *S3Bucket) Put(ctx context.Context, key string, content []byte, metadata map[string]string, optFns ...func(input *s3.PutObjectInput)) (string, error) {
+	return s.put(ctx, key, content, metadata, nil, optFns...)
+}
+
+// put is Put with extra client options, e.g. for headers not modeled by PutObjectInput
+func (s *S3Bucket) put(ctx context.Context, key string, content []byte, metadata map[string]string,
+	clientOptFns []func(*s3.Options), optFns ...func(input *s3.PutObjectInput)) (string, error) {
 	input := &s3.PutObjectInput{
 		Bucket:       aws.String(s.bucket),
-		Key:          aws.String(key),
-		Body:         bytes.NewBuffer(content),
+		Key:          aws.String(s.normalizeKey(key)),
+		Body:         bytes.NewReader(content),
 		ACL:          s.ACL,
-		CacheControl: aws.String(s.CacheControl),
+		CacheControl: s.cacheControlHeader(),
 		ContentType:  aws.String(http.DetectContentType(content)),
 		Metadata:     metadata,
+
+		ServerSideEncryption: s.ServerSideEncryption,
+		SSEKMSKeyId:          s.kmsKeyID(),
+	}
+	if s.ContentLanguage != "" {
+		input.ContentLanguage = aws.String(s.ContentLanguage)
 	}
 	for _, fn := range optFns {
 		fn(input)
 	}
-	output, err := s.client.PutObject(ctx, input)
+	if input.ContentLanguage != nil && !languageTagRegexp.MatchString(*input.ContentLanguage) {
+		return "", fmt.Errorf("invalid content language %s", *input.ContentLanguage)
+	}
+	output, err := s.client.PutObject(ctx, input, append(s.clientOptions(), clientOptFns...)...)
 	if err != nil {
-		return "", err
+		return "", wrapS3Error(ctx, "PutObject", key, err)
 	}
 	return xruntime.Dereference(output.ETag), nil
 }
 
-func (s *S3Bucket) Get(ctx context.Context, key string, optFns ...func(input *s3.GetObjectInput)) ([]byte, error) {
+// PutReader uploads size bytes from r without reading them into memory. Content type is detected from the first 512 bytes.
+func (s *S3Bucket) PutReader(ctx context.Context, key string, r io.Reader, size int64, metadata map[string]string, optFns ...func(input *s3.PutObjectInput)) (string, error) {
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read: %w", err)
+	}
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(s.normalizeKey(key)),
+		Body:          br,
+		ContentLength: size,
+		ACL:           s.ACL,
+		CacheControl:  s.cacheControlHeader(),
+		ContentType:   aws.String(http.DetectContentType(head)),
+		Metadata:      metadata,
+
+		ServerSideEncryption: s.ServerSideEncryption,
+		SSEKMSKeyId:          s.kmsKeyID(),
+	}
+	if s.ContentLanguage != "" {
+		input.ContentLanguage = aws.String(s.ContentLanguage)
+	}
+	for _, fn := range optFns {
+		fn(input)
+	}
+	if input.ContentLanguage != nil && !languageTagRegexp.MatchString(*input.ContentLanguage) {
+		return "", fmt.Errorf("invalid content language %s", *input.ContentLanguage)
+	}
+	output, err := s.client.PutObject(ctx, input, s.clientOptions()...)
+	if err != nil {
+		return "", wrapS3Error(ctx, "PutObject", key, err)
+	}
+	return xruntime.Dereference(output.ETag), nil
+}
+
+// PutStream is like PutReader for callers which don't need the ETag, e.g. to upload a request body as it's read
+func (s *S3Bucket) PutStream(ctx context.Context, key string, r io.Reader, size int64, metadata map[string]string, optFns ...func(input *s3.PutObjectInput)) error {
+	_, err := s.PutReader(ctx, key, r, size, metadata, optFns...)
+	return err
+}
+
+// PutWithOptions uploads content with opts which are validated before calling S3
+func (s *S3Bucket) PutWithOptions(ctx context.Context, key string, content []byte, opts PutOptions, optFns ...func(input *s3.PutObjectInput)) (string, error) {
+	if err := s.validateStorageClass(opts.StorageClass); err != nil {
+		return "", err
+	}
+	if err := validateTags(opts.Tags); err != nil {
+		return "", err
+	}
+	optFns = append([]func(*s3.PutObjectInput){func(input *s3.PutObjectInput) {
+		input.StorageClass = opts.StorageClass
+		if opts.ContentLanguage != "" {
+			input.ContentLanguage = aws.String(opts.ContentLanguage)
+		}
+		if opts.ContentDisposition != "" {
+			input.ContentDisposition = aws.String(opts.ContentDisposition)
+		}
+		if opts.ContentType != "" {
+			input.ContentType = aws.String(opts.ContentType)
+		}
+		if opts.ContentEncoding != "" {
+			input.ContentEncoding = aws.String(opts.ContentEncoding)
+		}
+		if opts.CacheControl != "" {
+			input.CacheControl = aws.String(opts.CacheControl)
+		}
+		if len(opts.Tags) != 0 {
+			input.Tagging = aws.String(encodeTags(opts.Tags))
+		}
+	}}, optFns...)
+	return s.Put(ctx, key, content, opts.Metadata, optFns...)
+}
+
+// PutIfChanged uploads content only if it differs from the stored object.
+// It compares the stored ETag with the MD5 of content, so objects whose ETag is not a plain MD5 (e.g. multipart uploads)
+// are always written. It returns true if an upload occurred.
+func (s *S3Bucket) PutIfChanged(ctx context.Context, key string, content []byte, metadata map[string]string, optFns ...func(input *s3.PutObjectInput)) (bool, error) {
+	head, err := s.GetHeadObject(ctx, key)
+	if err != nil && !xerror.IsNotExist(err) {
+		return false, fmt.Errorf("get head object: %w", err)
+	}
+
+	if head != nil {
+		if etag, ok := md5ETag(head.ETag); ok && etag == contentMD5(content) {
+			return false, nil
+		}
+	}
+
+	_, err = s.Put(ctx, key, content, metadata, optFns...)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetStream returns the object's content as a stream instead of reading it into memory, e.g. to pipe a large object onward.
+// The caller owns the reader and must close it, otherwise the underlying HTTP connection is leaked and never reused.
+// MaxObjectSize doesn't apply.
+func (s *S3Bucket) GetStream(ctx context.Context, key string, optFns ...func(input *s3.GetObjectInput)) (io.ReadCloser, error) {
+	output, err := s.getObject(ctx, key, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func (s *S3Bucket) getObject(ctx context.Context, key string, optFns ...func(input *s3.GetObjectInput)) (*s3.GetObjectOutput, error) {
 	input := &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Key:    aws.String(s.normalizeKey(key)),
 	}
 
 	for _, fn := range optFns {
 		fn(input)
 	}
 
-	output, err := s.client.GetObject(ctx, input)
+	output, err := s.client.GetObject(ctx, input, s.clientOptions()...)
 	if err != nil {
 		if _, ok := xerror.CauseOf[*types.NoSuchKey](err); ok {
 			return nil, xerror.NotFound("object %s doesn't exist", key)
 		}
-		return nil, fmt.Errorf("s3.GetObject: %w", err)
+		return nil, wrapS3Error(ctx, "GetObject", key, err)
+	}
+	return output, nil
+}
+
+// Get reads the object's content into memory. A zero-byte object yields an empty non-nil slice and nil error,
+// while a missing object yields a not-found error which xerror.IsNotExist reports, so the two can't be confused.
+func (s *S3Bucket) Get(ctx context.Context, key string, optFns ...func(input *s3.GetObjectInput)) ([]byte, error) {
+	output, err := s.getObject(ctx, key, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	content, err := s.readContent(key, output)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		content = []byte{}
+	}
+	return content, nil
+}
+
+// Object is an object's content along with its metadata
+type Object struct {
+	ObjectInfo
+	Content []byte
+}
+
+// GetWithMetadata is like Get but also returns metadata, content type and last modified time from the same response
+func (s *S3Bucket) GetWithMetadata(ctx context.Context, key string, optFns ...func(input *s3.GetObjectInput)) (*Object, error) {
+	output, err := s.getObject(ctx, key, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	content, err := s.readContent(key, output)
+	if err != nil {
+		return nil, err
+	}
+	return &Object{
+		ObjectInfo: ObjectInfo{
+			Key:          key,
+			Size:         output.ContentLength,
+			ETag:         xruntime.Dereference(output.ETag),
+			ContentType:  xruntime.Dereference(output.ContentType),
+			LastModified: xruntime.Dereference(output.LastModified),
+			Metadata:     output.Metadata,
+
+			ContentDisposition: xruntime.Dereference(output.ContentDisposition),
+		},
+		Content: content,
+	}, nil
+}
+
+// readContent reads output's body within MaxObjectSize
+func (s *S3Bucket) readContent(key string, output *s3.GetObjectOutput) ([]byte, error) {
+	if s.MaxObjectSize <= 0 {
+		content, err := io.ReadAll(output.Body)
+		if err != nil {
+			return nil, fmt.Errorf("io.ReadAll: %w", err)
+		}
+		return content, nil
 	}
 
-	content, err := io.ReadAll(output.Body)
+	if output.ContentLength > s.MaxObjectSize {
+		return nil, fmt.Errorf("%w: %s has %d bytes", ErrObjectTooLarge, key, output.ContentLength)
+	}
+	content, err := io.ReadAll(io.LimitReader(output.Body, s.MaxObjectSize+1))
 	if err != nil {
 		return nil, fmt.Errorf("io.ReadAll: %w", err)
 	}
-	output.Body.Close()
+	if int64(len(content)) > s.MaxObjectSize {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrObjectTooLarge, key, s.MaxObjectSize)
+	}
+	return content, nil
+}
+
+// PutVerified uploads content with Content-MD5 and then verifies the stored object's ETag against the content's MD5.
+// On mismatch it re-uploads once before returning ErrChecksumMismatch.
+// Objects whose stored ETag is not a plain MD5 (e.g. SSE-KMS) are verified by S3 via Content-MD5 only.
+func (s *S3Bucket) PutVerified(ctx context.Context, key string, content []byte, metadata map[string]string, optFns ...func(input *s3.PutObjectInput)) (string, error) {
+	sum := md5.Sum(content)
+	optFns = append(optFns, func(input *s3.PutObjectInput) {
+		input.ContentMD5 = aws.String(base64.StdEncoding.EncodeToString(sum[:]))
+	})
+
+	for attempt := 0; attempt < 2; attempt++ {
+		etag, err := s.Put(ctx, key, content, metadata, optFns...)
+		if err != nil || s.DryRun {
+			return etag, err
+		}
+
+		head, err := s.GetHeadObject(ctx, key)
+		if err != nil {
+			return "", fmt.Errorf("get head object: %w", err)
+		}
+
+		if stored, ok := md5ETag(head.ETag); !ok || stored == hex.EncodeToString(sum[:]) {
+			return etag, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrChecksumMismatch, key)
+}
 
+// ObjectResponse is an object's body with the headers needed to proxy it
+type ObjectResponse struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64
+	ETag          string
+	CacheControl  string
+	LastModified  time.Time
+
+	ContentDisposition string
+}
+
+// ObjectInfo is an object's attributes without its content
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string
+	ContentType  string
+	LastModified time.Time
+	Metadata     map[string]string
+
+	// ContentDisposition is empty for objects listed, as ListObjectsV2 doesn't return it
+	ContentDisposition string
+}
+
+func newObjectInfoFromHead(key string, head *s3.HeadObjectOutput) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          key,
+		Size:         head.ContentLength,
+		ETag:         xruntime.Dereference(head.ETag),
+		ContentType:  xruntime.Dereference(head.ContentType),
+		LastModified: xruntime.Dereference(head.LastModified),
+		Metadata:     head.Metadata,
+
+		ContentDisposition: xruntime.Dereference(head.ContentDisposition),
+	}
+}
+
+// GetResponse returns the object's body reader without reading it into memory, along with its headers.
+// The caller must close Body.
+func (s *S3Bucket) GetResponse(ctx context.Context, key string, optFns ...func(input *s3.GetObjectInput)) (*ObjectResponse, error) {
+	output, err := s.getObject(ctx, key, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectResponse{
+		Body:          output.Body,
+		ContentType:   xruntime.Dereference(output.ContentType),
+		ContentLength: output.ContentLength,
+		ETag:          xruntime.Dereference(output.ETag),
+		CacheControl:  xruntime.Dereference(output.CacheControl),
+		LastModified:  xruntime.Dereference(output.LastModified),
+
+		ContentDisposition: xruntime.Dereference(output.ContentDisposition),
+	}, nil
+}
+
+// GetN reads at most the first n bytes of an object with a ranged GET
+func (s *S3Bucket) GetN(ctx context.Context, key string, n int, optFns ...func(input *s3.GetObjectInput)) ([]byte, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	content, err := s.Get(ctx, key, append(optFns, func(input *s3.GetObjectInput) {
+		input.Range = aws.String(fmt.Sprintf("bytes=0-%d", n-1))
+	})...)
+	if err != nil {
+		// a ranged GET on an empty object is unsatisfiable
+		if apiErr, ok := xerror.CauseOf[smithy.APIError](err); ok && apiErr.ErrorCode() == "InvalidRange" {
+			return []byte{}, nil
+		}
+		return nil, err
+	}
 	return content, nil
 }
 
 func (s *S3Bucket) CreateMultipartUpload(ctx context.Context, key string, optFns ...func(*s3.CreateMultipartUploadInput)) (string, error) {
 	input := &s3.CreateMultipartUploadInput{
 		Bucket:       aws.String(s.bucket),
-		Key:          aws.String(key),
+		Key:          aws.String(s.normalizeKey(key)),
 		ACL:          s.ACL,
-		CacheControl: aws.String(s.CacheControl),
+		CacheControl: s.cacheControlHeader(),
+
+		ServerSideEncryption: s.ServerSideEncryption,
+		SSEKMSKeyId:          s.kmsKeyID(),
 	}
 	for _, fn := range optFns {
 		fn(input)
 	}
-	output, err := s.client.CreateMultipartUpload(ctx, input)
+	output, err := s.client.CreateMultipartUpload(ctx, input, s.clientOptions()...)
 	if err != nil {
-		return "", err
+		return "", wrapS3Error(ctx, "CreateMultipartUpload", key, err)
 	}
 	return *output.UploadId, nil
 }
@@ -123,7 +598,7 @@ func (s *S3Bucket) CreateMultipartUpload(ctx context.Context, key string, optFns
 func (s *S3Bucket) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []types.CompletedPart, optFns ...func(*s3.CompleteMultipartUploadInput)) (string, error) {
 	input := &s3.CompleteMultipartUploadInput{
 		Bucket:   aws.String(s.bucket),
-		Key:      aws.String(key),
+		Key:      aws.String(s.normalizeKey(key)),
 		UploadId: aws.String(uploadID),
 		MultipartUpload: &types.CompletedMultipartUpload{
 			Parts: parts,
@@ -132,9 +607,9 @@ func (s *S3Bucket) CompleteMultipartUpload(ctx context.Context, key, uploadID st
 	for _, fn := range optFns {
 		fn(input)
 	}
-	output, err := s.client.CompleteMultipartUpload(ctx, input)
+	output, err := s.client.CompleteMultipartUpload(ctx, input, s.clientOptions()...)
 	if err != nil {
-		return "", err
+		return "", wrapS3Error(ctx, "CompleteMultipartUpload", key, err)
 	}
 	return xruntime.Dereference(output.ETag), nil
 }
@@ -142,14 +617,14 @@ func (s *S3Bucket) CompleteMultipartUpload(ctx context.Context, key, uploadID st
 func (s *S3Bucket) AbortMultipartUpload(ctx context.Context, key, uploadID string, optFns ...func(*s3.AbortMultipartUploadInput)) error {
 	input := &s3.AbortMultipartUploadInput{
 		Bucket:   aws.String(s.bucket),
-		Key:      aws.String(key),
+		Key:      aws.String(s.normalizeKey(key)),
 		UploadId: aws.String(uploadID),
 	}
 	for _, fn := range optFns {
 		fn(input)
 	}
-	_, err := s.client.AbortMultipartUpload(ctx, input)
-	return err
+	_, err := s.client.AbortMultipartUpload(ctx, input, s.clientOptions()...)
+	return wrapS3Error(ctx, "AbortMultipartUpload", key, err)
 }
 
 func (s *S3Bucket) ListMultipartUploads(ctx context.Context, optFns ...func(*s3.ListMultipartUploadsInput)) ([]types.MultipartUpload, error) {
@@ -159,9 +634,9 @@ func (s *S3Bucket) ListMultipartUploads(ctx context.Context, optFns ...func(*s3.
 	for _, fn := range optFns {
 		fn(input)
 	}
-	output, err := s.client.ListMultipartUploads(ctx, input)
+	output, err := s.client.ListMultipartUploads(ctx, input, s.clientOptions()...)
 	if err != nil {
-		return nil, err
+		return nil, wrapS3Error(ctx, "ListMultipartUploads", "", err)
 	}
 	return output.Uploads, nil
 }
@@ -170,15 +645,15 @@ func (s *S3Bucket) ListMultipartUploads(ctx context.Context, optFns ...func(*s3.
 func (s *S3Bucket) ListParts(ctx context.Context, key, uploadID string, optFns ...func(input *s3.ListPartsInput)) ([]types.Part, error) {
 	input := &s3.ListPartsInput{
 		Bucket:   aws.String(s.bucket),
-		Key:      aws.String(key),
+		Key:      aws.String(s.normalizeKey(key)),
 		UploadId: aws.String(uploadID),
 	}
 	for _, fn := range optFns {
 		fn(input)
 	}
-	output, err := s.client.ListParts(ctx, input)
+	output, err := s.client.ListParts(ctx, input, s.clientOptions()...)
 	if err != nil {
-		return nil, err
+		return nil, wrapS3Error(ctx, "ListParts", key, err)
 	}
 	return output.Parts, nil
 }
@@ -186,135 +661,242 @@ func (s *S3Bucket) ListParts(ctx context.Context, key, uploadID string, optFns .
 func (s *S3Bucket) PreSignUploadPart(ctx context.Context, key, uploadID string, part int, ttl time.Duration, optFns ...func(*s3.UploadPartInput)) (*awssigner.PresignedHTTPRequest, error) {
 	input := &s3.UploadPartInput{
 		Bucket:     aws.String(s.bucket),
-		Key:        aws.String(key),
+		Key:        aws.String(s.normalizeKey(key)),
 		PartNumber: int32(part),
 		UploadId:   aws.String(uploadID),
 	}
 	for _, fn := range optFns {
 		fn(input)
 	}
-	return s.presignClient.PresignUploadPart(ctx, input, func(options *s3.PresignOptions) {
-		options.Expires = ttl
+	return s.presignClient.PresignUploadPart(ctx, input, s.presignOptions(ttl))
+}
+
+// WithPresignCredentials returns a copy of s whose presign methods sign with creds instead of the client's credentials,
+// e.g. STS session credentials of a role assumed for a tenant, so that presigned URLs are scoped by the role's policies.
+// A presigned URL stops working once the credentials it's signed with expire,
+// so its effective lifetime is the lesser of ttl and the remaining session duration.
+func (s *S3Bucket) WithPresignCredentials(creds aws.CredentialsProvider) *S3Bucket {
+	c := *s
+	c.presignClient = s3.NewPresignClient(s.client, func(options *s3.PresignOptions) {
+		options.ClientOptions = append(options.ClientOptions, func(o *s3.Options) {
+			o.Credentials = creds
+		})
 	})
+	return &c
 }
 
 func (s *S3Bucket) PreSignGet(ctx context.Context, key string, ttl time.Duration, optFns ...func(*s3.GetObjectInput)) (*awssigner.PresignedHTTPRequest, error) {
 	input := &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Key:    aws.String(s.normalizeKey(key)),
 	}
 	for _, fn := range optFns {
 		fn(input)
 	}
-	return s.presignClient.PresignGetObject(ctx, input, func(options *s3.PresignOptions) {
-		options.Expires = ttl
-	})
+	return s.presignClient.PresignGetObject(ctx, input, s.presignOptions(ttl))
 }
 
 func (s *S3Bucket) PreSignPut(ctx context.Context, key string, ttl time.Duration, optFns ...func(*s3.PutObjectInput)) (*awssigner.PresignedHTTPRequest, error) {
 	input := &s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Key:    aws.String(s.normalizeKey(key)),
 	}
 	for _, fn := range optFns {
 		fn(input)
 	}
-	return s.presignClient.PresignPutObject(ctx, input, func(options *s3.PresignOptions) {
-		options.Expires = ttl
-	})
+	return s.presignClient.PresignPutObject(ctx, input, s.presignOptions(ttl))
 }
 
 func (s *S3Bucket) Delete(ctx context.Context, key string, optFns ...func(*s3.DeleteObjectInput)) error {
 	input := &s3.DeleteObjectInput{
 		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Key:    aws.String(s.normalizeKey(key)),
 	}
 
-	_, err := s.client.DeleteObject(ctx, input)
-
 	for _, fn := range optFns {
 		fn(input)
 	}
 
+	_, err := s.client.DeleteObject(ctx, input, s.clientOptions()...)
 	if err != nil {
-		return fmt.Errorf("s3.DeleteObject: %w", err)
+		return wrapS3Error(ctx, "DeleteObject", key, err)
 	}
+	return s.waitDeleted(ctx, key)
+}
 
-	err = s.objNotExistsWaiter.Wait(ctx, &s3.HeadObjectInput{
+// waitDeleted waits up to DeleteWaitTimeout until key doesn't exist
+func (s *S3Bucket) waitDeleted(ctx context.Context, key string) error {
+	if s.DeleteWaitTimeout <= 0 || s.DryRun {
+		return nil
+	}
+	err := s.objNotExistsWaiter.Wait(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
-	}, time.Second*5)
+		Key:    aws.String(s.normalizeKey(key)),
+	}, s.DeleteWaitTimeout)
 	if err != nil {
-		return fmt.Errorf("s3.ObjectNotExistsWaiter.Wait: %w", err)
+		return fmt.Errorf("%w: %s: %v", ErrDeleteUnconfirmed, key, err)
 	}
 	return nil
 }
 
+// BatchDelete deletes objects in batches of 1000 keys and waits until the deletion is confirmed.
+// Only the first key is checked rather than sending a HEAD request per key. As S3 is strongly consistent,
+// it confirms that the batch took effect, not that every key is gone, which BatchDeleteResult reports.
+// Keys which can't be deleted are reported together across all batches by BatchDeleteError.
+// Use BatchDeleteResult for the result of each key.
 func (s *S3Bucket) BatchDelete(ctx context.Context, ids []string, optFns ...func(*s3.DeleteObjectsInput)) error {
 	if len(ids) == 0 {
 		return nil
 	}
 
-	input := &s3.DeleteObjectsInput{
-		Bucket: aws.String(s.bucket),
-		Delete: &types.Delete{
-			Objects: xslice.MustTransform(ids, func(key string) types.ObjectIdentifier {
-				return types.ObjectIdentifier{
-					Key: aws.String(key),
-				}
-			}),
-		},
+	result, err := s.BatchDeleteResult(ctx, ids, optFns...)
+	if err != nil {
+		return err
 	}
 
+	if err = newBatchDeleteError(result); err != nil {
+		return err
+	}
+	return s.waitDeleted(ctx, ids[0])
+}
+
+func (s *S3Bucket) GetHeadObject(ctx context.Context, key string, optFns ...func(*s3.HeadObjectInput)) (*s3.HeadObjectOutput, error) {
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.normalizeKey(key)),
+	}
 	for _, fn := range optFns {
 		fn(input)
 	}
-
-	output, err := s.client.DeleteObjects(ctx, input)
+	output, err := s.client.HeadObject(ctx, input, s.clientOptions()...)
 	if err != nil {
-		return fmt.Errorf("s3.DeleteObjects: %w", err)
+		if apiErr, ok := xerror.CauseOf[smithy.APIError](err); ok {
+			if apiErr.ErrorCode() == s3ErrorNotFound.ErrorCode() {
+				return nil, xerror.NotFound("key")
+			}
+		}
+		return nil, wrapS3Error(ctx, "HeadObject", key, err)
 	}
+	return output, nil
+}
 
-	if len(output.Deleted) == 0 {
-		return nil
+// Exists returns whether the object exists. See ExistsViaList.
+func (s *S3Bucket) Exists(ctx context.Context, key string) (bool, error) {
+	if s.ExistsViaList {
+		// key sorts first among keys with prefix key, so one key is enough
+		output, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:  aws.String(s.bucket),
+			Prefix:  aws.String(s.normalizeKey(key)),
+			MaxKeys: 1,
+		}, s.clientOptions()...)
+		if err != nil {
+			return false, wrapS3Error(ctx, "ListObjectsV2", key, err)
+		}
+		return len(output.Contents) != 0 && aws.ToString(output.Contents[0].Key) == s.normalizeKey(key), nil
 	}
 
-	if len(output.Deleted) != len(ids) {
-		idSet := xslice.MustToSet[string, string](ids, nil)
-		for _, del := range output.Deleted {
-			delete(idSet, *del.Key)
-		}
-		if len(idSet) != 0 {
-			return fmt.Errorf("some ids cannot be deleted: %v", xmap.GetKeys(idSet))
+	_, err := s.GetHeadObject(ctx, key)
+	if err != nil {
+		if xerror.IsNotExist(err) {
+			return false, nil
 		}
+		return false, err
 	}
+	return true, nil
+}
 
-	err = s.objNotExistsWaiter.Wait(ctx, &s3.HeadObjectInput{
+// Warmup establishes the connection to S3 (DNS, TCP and TLS) with a HEAD request on a sentinel key.
+// Call it during initialization, e.g. in Lambda's init phase, to reduce the latency of the first real request.
+// Any response from S3, including not found and access denied, counts as success.
+func (s *S3Bucket) Warmup(ctx context.Context) error {
+	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(s.bucket),
-		Key:    aws.String(ids[0]),
-	}, time.Second*5)
+		Key:    aws.String(".awskit-warmup"),
+	}, s.clientOptions()...)
+	if err == nil {
+		return nil
+	}
+	if _, ok := xerror.CauseOf[*awshttp.ResponseError](err); ok {
+		return nil
+	}
+	return wrapS3Error(ctx, "HeadObject", "", err)
+}
+
+// CacheTag returns a short token derived from the object's ETag and last-modified time.
+// The token changes whenever the object changes, so it can be embedded in URLs (e.g. ?v=...) to bust caches.
+func (s *S3Bucket) CacheTag(ctx context.Context, key string) (string, error) {
+	head, err := s.GetHeadObject(ctx, key)
 	if err != nil {
-		return fmt.Errorf("s3.ObjectNotExistsWaiter.Wait: %w", err)
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(xruntime.Dereference(head.ETag)))
+	if head.LastModified != nil {
+		h.Write([]byte(head.LastModified.UTC().Format(time.RFC3339Nano)))
+	}
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:9]), nil
+}
+
+func (s *S3Bucket) normalizeKey(key string) string {
+	switch s.KeyCase {
+	case KeyCaseLower:
+		return strings.ToLower(key)
+	case KeyCaseUpper:
+		return strings.ToUpper(key)
+	default:
+		return key
+	}
+}
+
+func (s *S3Bucket) cacheControlHeader() *string {
+	if s.CacheControl == "" {
+		return nil
+	}
+	return aws.String(s.CacheControl)
+}
+
+func (s *S3Bucket) kmsKeyID() *string {
+	if s.KMSKeyID == "" {
+		return nil
+	}
+	return aws.String(s.KMSKeyID)
+}
+
+func (s *S3Bucket) validateStorageClass(class types.StorageClass) error {
+	if class == "" {
+		return nil
+	}
+
+	if !slices.Contains(class.Values(), class) {
+		return fmt.Errorf("unknown storage class %s", class)
+	}
+
+	if class == types.StorageClassOutposts && !strings.Contains(s.bucket, ":s3-outposts:") {
+		return fmt.Errorf("storage class %s requires an S3 on Outposts bucket", class)
+	}
+
+	if len(s.AllowedStorageClasses) != 0 && !slices.Contains(s.AllowedStorageClasses, class) {
+		return fmt.Errorf("storage class %s is not allowed for bucket %s", class, s.bucket)
 	}
 	return nil
 }
 
-func (s *S3Bucket) GetHeadObject(ctx context.Context, key string, optFns ...func(*s3.HeadObjectInput)) (*s3.HeadObjectOutput, error) {
-	input := &s3.HeadObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+// md5ETag returns the unquoted etag if it is a plain MD5 digest
+func md5ETag(etag *string) (string, bool) {
+	if etag == nil {
+		return "", false
 	}
-	for _, fn := range optFns {
-		fn(input)
+	v := strings.Trim(*etag, `"`)
+	if len(v) != md5.Size*2 {
+		return "", false
 	}
-	output, err := s.client.HeadObject(ctx, input)
-	if err != nil {
-		if apiErr, ok := xerror.CauseOf[smithy.APIError](err); ok {
-			if apiErr.ErrorCode() == s3ErrorNotFound.ErrorCode() {
-				return nil, xerror.NotFound("key")
-			}
-		}
-		return nil, err
+	if _, err := hex.DecodeString(v); err != nil {
+		return "", false
 	}
-	return output, nil
+	return strings.ToLower(v), true
+}
+
+func contentMD5(content []byte) string {
+	sum := md5.Sum(content)
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,71 @@
+package awskit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// StorageClassError is returned by Archive if the object's storage class didn't change after copying
+type StorageClassError struct {
+	Key  string
+	Want types.StorageClass
+	Got  types.StorageClass
+}
+
+func (e *StorageClassError) Error() string {
+	return fmt.Sprintf("storage class of %s is %s, expected %s", e.Key, e.Got, e.Want)
+}
+
+// Archive moves the object to storage class by copying it onto itself, then verifies the new class with HEAD.
+// It's a no-op if the object is in class already. Metadata and headers like Content-Type are preserved.
+// Objects in GLACIER or DEEP_ARCHIVE must be restored before they can be moved, see EnsureRetrievable.
+func (s *S3Bucket) Archive(ctx context.Context, key string, class types.StorageClass) error {
+	if class == "" {
+		return fmt.Errorf("missing storage class")
+	}
+	if err := s.validateStorageClass(class); err != nil {
+		return err
+	}
+	head, err := s.GetHeadObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	if storageClassOf(head.StorageClass) == class {
+		return nil
+	}
+
+	input := s.newCopyObjectInput(key, key)
+	input.MetadataDirective = types.MetadataDirectiveReplace
+	input.Metadata = head.Metadata
+	input.ContentType = head.ContentType
+	input.ContentEncoding = head.ContentEncoding
+	input.ContentLanguage = head.ContentLanguage
+	input.ContentDisposition = head.ContentDisposition
+	input.CacheControl = head.CacheControl
+	input.StorageClass = class
+	if _, err = s.client.CopyObject(ctx, input, s.clientOptions()...); err != nil {
+		return wrapS3Error(ctx, "CopyObject", key, err)
+	}
+	if s.DryRun {
+		return nil
+	}
+
+	head, err = s.GetHeadObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	if got := storageClassOf(head.StorageClass); got != class {
+		return &StorageClassError{Key: key, Want: class, Got: got}
+	}
+	return nil
+}
+
+// storageClassOf converts HEAD's storage class, which is omitted for STANDARD
+func storageClassOf(class types.StorageClass) types.StorageClass {
+	if class == "" {
+		return types.StorageClassStandard
+	}
+	return class
+}
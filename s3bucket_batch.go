@@ -0,0 +1,228 @@
+package awskit
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"code.olapie.com/sugar/v2/xerror"
+	"code.olapie.com/sugar/v2/xruntime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// ItemResult is the result of a batch operation on one key
+type ItemResult[T any] struct {
+	Key   string
+	Value T
+	Err   error
+}
+
+// BatchResult holds per-key results of a batch operation in the order of the keys
+type BatchResult[T any] struct {
+	Items []*ItemResult[T]
+}
+
+// Succeeded returns the number of keys without error
+func (r *BatchResult[T]) Succeeded() int {
+	n := 0
+	for _, item := range r.Items {
+		if item.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the number of keys with error
+func (r *BatchResult[T]) Failed() int {
+	return len(r.Items) - r.Succeeded()
+}
+
+// Values returns values of succeeded keys
+func (r *BatchResult[T]) Values() map[string]T {
+	values := make(map[string]T, len(r.Items))
+	for _, item := range r.Items {
+		if item.Err == nil {
+			values[item.Key] = item.Value
+		}
+	}
+	return values
+}
+
+// Err returns failures as ObjectErrors, or nil if all keys succeeded
+func (r *BatchResult[T]) Err() error {
+	var errs ObjectErrors
+	for _, item := range r.Items {
+		if item.Err != nil {
+			errs = append(errs, &ObjectError{Key: item.Key, Err: item.Err})
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// BatchDeleteResult deletes objects and reports the result of each key. Keys are deleted in sequential requests
+// of up to 1000 keys which is the limit of DeleteObjects. If a request fails, the error is returned
+// and later keys are not deleted. Unlike BatchDelete it doesn't wait for the deletion to be confirmed.
+func (s *S3Bucket) BatchDeleteResult(ctx context.Context, keys []string, optFns ...func(*s3.DeleteObjectsInput)) (*BatchResult[struct{}], error) {
+	result := &BatchResult[struct{}]{Items: make([]*ItemResult[struct{}], 0, len(keys))}
+	for start := 0; start < len(keys); start += maxDeleteObjects {
+		end := start + maxDeleteObjects
+		if end > len(keys) {
+			end = len(keys)
+		}
+		items, err := s.deleteObjects(ctx, keys[start:end], optFns)
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, items...)
+	}
+	return result, nil
+}
+
+// deleteObjects deletes at most maxDeleteObjects keys in one request.
+// Keys with the same normalized key, e.g. Foo and foo with KeyCaseLower, are sent once and share the result.
+func (s *S3Bucket) deleteObjects(ctx context.Context, keys []string, optFns []func(*s3.DeleteObjectsInput)) ([]*ItemResult[struct{}], error) {
+	items := make([]*ItemResult[struct{}], len(keys))
+	input := &s3.DeleteObjectsInput{
+		Bucket: aws.String(s.bucket),
+		Delete: &types.Delete{
+			Objects: make([]types.ObjectIdentifier, 0, len(keys)),
+		},
+	}
+	byKey := make(map[string][]*ItemResult[struct{}], len(keys))
+	for i, key := range keys {
+		items[i] = &ItemResult[struct{}]{Key: key}
+		normalized := s.normalizeKey(key)
+		if _, ok := byKey[normalized]; !ok {
+			input.Delete.Objects = append(input.Delete.Objects, types.ObjectIdentifier{Key: aws.String(normalized)})
+		}
+		byKey[normalized] = append(byKey[normalized], items[i])
+	}
+	for _, fn := range optFns {
+		fn(input)
+	}
+
+	output, err := s.client.DeleteObjects(ctx, input, s.clientOptions()...)
+	if err != nil {
+		return nil, wrapS3Error(ctx, "DeleteObjects", keys[0], err)
+	}
+
+	// per-key errors come with a successful response, whose ids identify the request in support cases
+	requestID, _ := awsmiddleware.GetRequestIDMetadata(output.ResultMetadata)
+	hostID, _ := s3.GetHostIDMetadata(output.ResultMetadata)
+
+	// keys not reported in Errors are deleted, which also works in quiet mode where Deleted is empty
+	for _, e := range output.Errors {
+		for _, item := range byKey[xruntime.Dereference(e.Key)] {
+			// smithy.APIError exposes the code, e.g. to tell throttling from other failures
+			var err error = &smithy.GenericAPIError{
+				Code:    xruntime.Dereference(e.Code),
+				Message: xruntime.Dereference(e.Message),
+			}
+			if xruntime.Dereference(e.Code) == "AccessDenied" {
+				err = &AccessDeniedError{
+					Op:          "DeleteObjects",
+					Key:         item.Key,
+					Attribution: GetAttribution(ctx),
+					RequestID:   requestID,
+					HostID:      hostID,
+					Err:         err,
+				}
+			}
+			item.Err = err
+		}
+	}
+	return items, nil
+}
+
+// BatchStat gets ObjectInfo of keys with at most concurrency goroutines
+func (s *S3Bucket) BatchStat(ctx context.Context, keys []string, concurrency int) *BatchResult[*ObjectInfo] {
+	return batchDo(keys, concurrency, func(key string) (*ObjectInfo, error) {
+		head, err := s.GetHeadObject(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return newObjectInfoFromHead(key, head), nil
+	})
+}
+
+// BatchExists checks existence of keys with at most concurrency goroutines. Missing objects are not errors.
+func (s *S3Bucket) BatchExists(ctx context.Context, keys []string, concurrency int) *BatchResult[bool] {
+	return batchDo(keys, concurrency, func(key string) (bool, error) {
+		return s.Exists(ctx, key)
+	})
+}
+
+// BatchGet reads objects with at most concurrency goroutines. Missing objects are left out of the map rather than failing the batch,
+// other failures are returned as ObjectErrors along with the objects which were read. It returns ctx's error if ctx is done.
+func (s *S3Bucket) BatchGet(ctx context.Context, keys []string, concurrency int) (map[string][]byte, error) {
+	result := batchDo(keys, concurrency, func(key string) ([]byte, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return s.Get(ctx, key)
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string][]byte, len(keys))
+	var errs ObjectErrors
+	for _, item := range result.Items {
+		switch {
+		case item.Err == nil:
+			contents[item.Key] = item.Value
+		case xerror.IsNotExist(item.Err):
+		default:
+			errs = append(errs, &ObjectError{Key: item.Key, Err: item.Err})
+		}
+	}
+	if len(errs) != 0 {
+		return contents, errs
+	}
+	return contents, nil
+}
+
+// BatchPut uploads objects with at most concurrency goroutines. Values of results are ETags.
+func (s *S3Bucket) BatchPut(ctx context.Context, objects map[string][]byte, concurrency int, optFns ...func(input *s3.PutObjectInput)) *BatchResult[string] {
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return batchDo(keys, concurrency, func(key string) (string, error) {
+		return s.Put(ctx, key, objects[key], nil, optFns...)
+	})
+}
+
+// batchDo runs fn for each key with at most concurrency goroutines
+func batchDo[T any](keys []string, concurrency int, fn func(key string) (T, error)) *BatchResult[T] {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	result := &BatchResult[T]{Items: make([]*ItemResult[T], len(keys))}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, key := range keys {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, key string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			item := &ItemResult[T]{Key: key}
+			item.Value, item.Err = fn(key)
+			result.Items[i] = item
+		}(i, key)
+	}
+	wg.Wait()
+	return result
+}
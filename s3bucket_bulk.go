@@ -0,0 +1,192 @@
+package awskit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type MapOptions struct {
+	// Metadata is merged into each source object's metadata, overriding keys which exist in both
+	Metadata map[string]string
+
+	// ContentType overrides the source object's content type if not empty, e.g. after re-encoding
+	ContentType string
+}
+
+// MapObjects reads each object under srcPrefix, transforms its content and writes the result to dst under dstPrefix,
+// e.g. srcPrefix/a/b is written to dstPrefix/a/b. At most concurrency objects are processed at the same time.
+// The source's metadata and content type are preserved unless overridden by MapOptions.
+// Per-object failures don't stop the others and are returned as ObjectErrors.
+func (s *S3Bucket) MapObjects(ctx context.Context, srcPrefix string, dst *S3Bucket, dstPrefix string,
+	transform func(key string, data []byte) ([]byte, error), concurrency int, optFns ...func(options *MapOptions)) error {
+	options := new(MapOptions)
+	for _, fn := range optFns {
+		fn(options)
+	}
+	var mu sync.Mutex
+	var errs ObjectErrors
+	err := s.forEachObjectConcurrently(ctx, srcPrefix, concurrency, func(obj types.Object) {
+		key := *obj.Key
+		if err := s.mapObject(ctx, key, dst, dstPrefix+strings.TrimPrefix(key, s.normalizeKey(srcPrefix)), transform, options); err != nil {
+			mu.Lock()
+			errs = append(errs, &ObjectError{Key: key, Err: err})
+			mu.Unlock()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s *S3Bucket) mapObject(ctx context.Context, key string, dst *S3Bucket, dstKey string,
+	transform func(key string, data []byte) ([]byte, error), options *MapOptions) error {
+	obj, err := s.GetWithMetadata(ctx, key)
+	if err != nil {
+		return err
+	}
+	data, err := transform(key, obj.Content)
+	if err != nil {
+		return fmt.Errorf("transform: %w", err)
+	}
+
+	metadata := make(map[string]string, len(obj.Metadata)+len(options.Metadata))
+	for k, v := range obj.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range options.Metadata {
+		metadata[k] = v
+	}
+	contentType := obj.ContentType
+	if options.ContentType != "" {
+		contentType = options.ContentType
+	}
+	_, err = dst.Put(ctx, dstKey, data, metadata, func(input *s3.PutObjectInput) {
+		if contentType != "" {
+			input.ContentType = aws.String(contentType)
+		}
+	})
+	return err
+}
+
+// TagPrefix replaces tags of all objects under prefix with at most concurrency goroutines.
+// It returns the number of updated objects. Per-object failures are returned as ObjectErrors.
+func (s *S3Bucket) TagPrefix(ctx context.Context, prefix string, tags map[string]string, concurrency int) (int, error) {
+	if err := validateTags(tags); err != nil {
+		return 0, err
+	}
+	tagging := &types.Tagging{
+		TagSet: make([]types.Tag, 0, len(tags)),
+	}
+	for k, v := range tags {
+		tagging.TagSet = append(tagging.TagSet, types.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(v),
+		})
+	}
+
+	var mu sync.Mutex
+	var errs ObjectErrors
+	var updated int
+	err := s.forEachObjectConcurrently(ctx, prefix, concurrency, func(obj types.Object) {
+		_, err := s.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+			Bucket:  aws.String(s.bucket),
+			Key:     obj.Key,
+			Tagging: tagging,
+		}, s.clientOptions()...)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, &ObjectError{Key: *obj.Key, Err: wrapS3Error(ctx, "PutObjectTagging", *obj.Key, err)})
+		} else {
+			updated++
+		}
+	})
+	if err != nil {
+		return updated, err
+	}
+	if len(errs) != 0 {
+		return updated, errs
+	}
+	return updated, nil
+}
+
+// maxDeleteObjects is the max number of keys in a single DeleteObjects request
+const maxDeleteObjects = 1000
+
+// DeleteOlderThan deletes objects under prefix whose LastModified is older than age.
+// Objects are deleted in batches while listing, and it stops at the first batch with failures, which are returned
+// as BatchDeleteError. The returned count includes deleted keys of that batch.
+func (s *S3Bucket) DeleteOlderThan(ctx context.Context, prefix string, age time.Duration) (int, error) {
+	cutoff := time.Now().Add(-age)
+	var deleted int
+	keys := make([]string, 0, maxDeleteObjects)
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		result, err := s.BatchDeleteResult(ctx, keys)
+		if err != nil {
+			return err
+		}
+		deleted += len(keys) - result.Failed()
+		keys = keys[:0]
+		return newBatchDeleteError(result)
+	}
+
+	err := s.forEachObject(ctx, prefix, func(obj types.Object) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if obj.LastModified == nil || !obj.LastModified.Before(cutoff) {
+			return nil
+		}
+		keys = append(keys, *obj.Key)
+		if len(keys) < maxDeleteObjects {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// forEachObjectConcurrently lists objects under prefix and runs fn for each object with at most concurrency goroutines.
+// It returns after all started fn calls finish.
+func (s *S3Bucket) forEachObjectConcurrently(ctx context.Context, prefix string, concurrency int, fn func(obj types.Object)) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	err := s.forEachObject(ctx, prefix, func(obj types.Object) error {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(obj)
+		}()
+		return nil
+	})
+	wg.Wait()
+	return err
+}
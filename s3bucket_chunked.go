@@ -0,0 +1,189 @@
+package awskit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"code.olapie.com/log"
+	"code.olapie.com/sugar/v2/xerror"
+	"github.com/google/uuid"
+)
+
+// deleteChunksTimeout bounds cleanup of chunks, which runs after ctx may be done
+const deleteChunksTimeout = 30 * time.Second
+
+// ChunkManifest describes an object stored by PutChunked
+type ChunkManifest struct {
+	Size      int64    `json:"size"`
+	ChunkSize int64    `json:"chunk_size"`
+	Chunks    []*Chunk `json:"chunks"`
+}
+
+type Chunk struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+	ETag string `json:"etag"`
+}
+
+// chunkKey is unique per upload, so that an overwrite never touches chunks of the current manifest
+func chunkKey(key, uploadID string, i int) string {
+	return fmt.Sprintf("%s/%s/part-%04d", key, uploadID, i)
+}
+
+func manifestKey(key string) string {
+	return key + "/manifest"
+}
+
+// PutChunked reads r and stores it as chunks of at most chunkSize bytes under key/<upload id>/part-0000,
+// key/<upload id>/part-0001 ... and a manifest under key/manifest, for backends which limit object size.
+// Only one chunk is held in memory at a time. The manifest is switched after all chunks are written,
+// so a failed overwrite keeps the previous object intact. Chunks of the failed upload, or of the previous object
+// once it's replaced, are deleted.
+func (s *S3Bucket) PutChunked(ctx context.Context, key string, r io.Reader, chunkSize int64) (err error) {
+	if chunkSize <= 0 {
+		return fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+	previous, err := s.GetChunkManifest(ctx, key)
+	if err != nil && !xerror.IsNotExist(err) {
+		return fmt.Errorf("get manifest: %w", err)
+	}
+
+	uploadID := uuid.NewString()
+	manifest := &ChunkManifest{ChunkSize: chunkSize}
+	defer func() {
+		if err != nil {
+			s.deleteChunks(ctx, manifest)
+		}
+	}()
+
+	buf := make([]byte, chunkSize)
+	for i := 0; ; i++ {
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && !errors.Is(readErr, io.ErrUnexpectedEOF) && !errors.Is(readErr, io.EOF) {
+			return fmt.Errorf("read: %w", readErr)
+		}
+		if n == 0 && i > 0 {
+			break
+		}
+		chunk := &Chunk{Key: chunkKey(key, uploadID, i), Size: int64(n)}
+		chunk.ETag, err = s.Put(ctx, chunk.Key, buf[:n], nil)
+		if err != nil {
+			return fmt.Errorf("put chunk %d: %w", i, err)
+		}
+		manifest.Chunks = append(manifest.Chunks, chunk)
+		manifest.Size += int64(n)
+		if readErr != nil {
+			break
+		}
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	if _, err = s.Put(ctx, manifestKey(key), data, nil); err != nil {
+		return fmt.Errorf("put manifest: %w", err)
+	}
+	if previous != nil {
+		s.deleteChunks(ctx, previous)
+	}
+	return nil
+}
+
+// GetChunkManifest reads the manifest of an object stored by PutChunked
+func (s *S3Bucket) GetChunkManifest(ctx context.Context, key string) (*ChunkManifest, error) {
+	data, err := s.Get(ctx, manifestKey(key))
+	if err != nil {
+		return nil, err
+	}
+	manifest := new(ChunkManifest)
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return manifest, nil
+}
+
+// GetChunked returns a reader of an object stored by PutChunked. Chunks are fetched one by one while reading.
+// It returns ErrObjectTooLarge if the object exceeds MaxObjectSize, and so does the reader if chunks turn out larger
+// than the manifest says. The caller must close the reader.
+func (s *S3Bucket) GetChunked(ctx context.Context, key string) (io.ReadCloser, error) {
+	manifest, err := s.GetChunkManifest(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if s.MaxObjectSize > 0 && manifest.Size > s.MaxObjectSize {
+		return nil, fmt.Errorf("%w: %s has %d bytes", ErrObjectTooLarge, key, manifest.Size)
+	}
+	return &chunkReader{ctx: ctx, bucket: s, key: key, chunks: manifest.Chunks}, nil
+}
+
+// deleteChunks deletes manifest's chunks with a fresh context, as ctx may be done already.
+// Failures are only logged, leaving orphan chunks which don't affect readers.
+func (s *S3Bucket) deleteChunks(ctx context.Context, manifest *ChunkManifest) {
+	if len(manifest.Chunks) == 0 {
+		return
+	}
+	keys := make([]string, len(manifest.Chunks))
+	for i, c := range manifest.Chunks {
+		keys[i] = c.Key
+	}
+	deleteCtx, cancel := context.WithTimeout(context.Background(), deleteChunksTimeout)
+	defer cancel()
+	if err := s.BatchDelete(deleteCtx, keys); err != nil {
+		log.FromContext(ctx).Warn("Cannot delete chunks", log.String("key", keys[0]), log.Error(err))
+	}
+}
+
+type chunkReader struct {
+	ctx     context.Context
+	bucket  *S3Bucket
+	key     string
+	chunks  []*Chunk
+	current io.ReadCloser
+	read    int64
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.chunks) == 0 {
+				return 0, io.EOF
+			}
+			resp, err := r.bucket.GetResponse(r.ctx, r.chunks[0].Key)
+			if err != nil {
+				return 0, fmt.Errorf("get chunk %s: %w", r.chunks[0].Key, err)
+			}
+			r.current = resp.Body
+			r.chunks = r.chunks[1:]
+		}
+
+		n, err := r.current.Read(p)
+		r.read += int64(n)
+		if limit := r.bucket.MaxObjectSize; limit > 0 && r.read > limit {
+			return 0, fmt.Errorf("%w: %s exceeds %d bytes", ErrObjectTooLarge, r.key, limit)
+		}
+		if errors.Is(err, io.EOF) {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	r.chunks = nil
+	return err
+}
@@ -0,0 +1,52 @@
+package awskit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestS3_PutChunked_Overwrite(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	require.NoError(t, bucket.PutChunked(ctx, "video", strings.NewReader("0123456789"), 4))
+	first, err := bucket.GetChunkManifest(ctx, "video")
+	require.NoError(t, err)
+	require.Len(t, first.Chunks, 3)
+
+	// a failed overwrite keeps the previous object readable
+	err = bucket.PutChunked(ctx, "video", io.MultiReader(strings.NewReader("abcdefgh"), failingReader{}), 4)
+	require.Error(t, err)
+	r, err := bucket.GetChunked(ctx, "video")
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "0123456789", string(content))
+
+	// a shorter overwrite deletes all previous chunks
+	require.NoError(t, bucket.PutChunked(ctx, "video", bytes.NewReader([]byte("abc")), 4))
+	for _, c := range first.Chunks {
+		_, ok := fake.Object(c.Key)
+		require.False(t, ok, c.Key)
+	}
+	r, err = bucket.GetChunked(ctx, "video")
+	require.NoError(t, err)
+	content, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "abc", string(content))
+}
@@ -0,0 +1,166 @@
+package awskit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.olapie.com/sugar/v2/xerror"
+	"code.olapie.com/sugar/v2/xruntime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// ConflictError is returned by conditional writes if the precondition fails.
+// Current is the object's state right after the conflict, or nil if it doesn't exist.
+// It matches ErrPreconditionFailed with errors.Is.
+type ConflictError struct {
+	Key     string
+	Current *ObjectInfo
+	Err     error
+}
+
+func (e *ConflictError) Error() string {
+	if e.Current == nil {
+		return fmt.Sprintf("%s: %s: %v", ErrPreconditionFailed, e.Key, e.Err)
+	}
+	return fmt.Sprintf("%s: %s has etag %s: %v", ErrPreconditionFailed, e.Key, e.Current.ETag, e.Err)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrPreconditionFailed
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// PutIfMatch uploads content only if the stored object's ETag equals etag.
+// On conflict it returns *ConflictError carrying the current object info.
+func (s *S3Bucket) PutIfMatch(ctx context.Context, key string, content []byte, etag string, metadata map[string]string, optFns ...func(input *s3.PutObjectInput)) (string, error) {
+	return s.putConditionally(ctx, key, content, metadata, "If-Match", etag, optFns...)
+}
+
+// PutIfNoneMatch uploads content only if the object doesn't exist.
+// On conflict it returns *ConflictError carrying the current object info.
+func (s *S3Bucket) PutIfNoneMatch(ctx context.Context, key string, content []byte, metadata map[string]string, optFns ...func(input *s3.PutObjectInput)) (string, error) {
+	return s.putConditionally(ctx, key, content, metadata, "If-None-Match", "*", optFns...)
+}
+
+func (s *S3Bucket) putConditionally(ctx context.Context, key string, content []byte, metadata map[string]string,
+	header, value string, optFns ...func(input *s3.PutObjectInput)) (string, error) {
+	setHeader := func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions[:len(o.APIOptions):len(o.APIOptions)], addRequestHeader(header, value))
+	}
+	etag, err := s.put(ctx, key, content, metadata, []func(*s3.Options){setHeader}, optFns...)
+	if err == nil {
+		return etag, nil
+	}
+	if !isPreconditionFailed(err) && !isConditionalConflict(err) {
+		return "", err
+	}
+
+	conflict := &ConflictError{Key: key, Err: err}
+	head, headErr := s.GetHeadObject(ctx, key)
+	if headErr != nil && !xerror.IsNotExist(headErr) {
+		return "", fmt.Errorf("get head object: %w", headErr)
+	}
+	if head != nil {
+		conflict.Current = newObjectInfoFromHead(key, head)
+	}
+	return "", conflict
+}
+
+// maxIncrementAttempts bounds retries of Increment on conflicts
+const maxIncrementAttempts = 5
+
+// Increment adds delta to the decimal counter stored at key and returns the new value.
+// A missing counter starts from zero. It's a read-modify-write guarded by If-Match and retried on conflicts,
+// so it's only suitable for low contention, e.g. download counts. Heavily contended counters belong in DynamoDB.
+func (s *S3Bucket) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	var err error
+	for attempt := 0; attempt < maxIncrementAttempts; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(attempt)*20*time.Millisecond + time.Duration(rand.Int63n(int64(20*time.Millisecond)))
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+
+		var value int64
+		var etag string
+		value, etag, err = s.getCounter(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		value += delta
+		content := []byte(strconv.FormatInt(value, 10))
+		setType := func(input *s3.PutObjectInput) {
+			input.ContentType = aws.String("text/plain")
+		}
+		if etag == "" {
+			_, err = s.PutIfNoneMatch(ctx, key, content, nil, setType)
+		} else {
+			_, err = s.PutIfMatch(ctx, key, content, etag, nil, setType)
+		}
+		if err == nil {
+			return value, nil
+		}
+		if !errors.Is(err, ErrPreconditionFailed) {
+			return 0, err
+		}
+	}
+	return 0, fmt.Errorf("increment %s: %w", key, err)
+}
+
+// getCounter returns the counter's value and ETag, or zero and empty ETag if it doesn't exist
+func (s *S3Bucket) getCounter(ctx context.Context, key string) (int64, string, error) {
+	output, err := s.getObject(ctx, key)
+	if err != nil {
+		if xerror.IsNotExist(err) {
+			return 0, "", nil
+		}
+		return 0, "", err
+	}
+	defer output.Body.Close()
+	content, err := s.readContent(key, output)
+	if err != nil {
+		return 0, "", err
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid counter %s: %w", key, err)
+	}
+	return value, xruntime.Dereference(output.ETag), nil
+}
+
+// isConditionalConflict reports if a conditional write conflicted with a concurrent write
+func isConditionalConflict(err error) bool {
+	if apiErr, ok := xerror.CauseOf[smithy.APIError](err); ok {
+		return apiErr.ErrorCode() == "ConditionalRequestConflict"
+	}
+	return false
+}
+
+// addRequestHeader returns an API option which sets a header on the HTTP request
+func addRequestHeader(key, value string) func(stack *middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("AwskitHeader"+key, func(
+			ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler,
+		) (middleware.BuildOutput, middleware.Metadata, error) {
+			if req, ok := in.Request.(*smithyhttp.Request); ok {
+				req.Header.Set(key, value)
+			}
+			return next.HandleBuild(ctx, in)
+		}), middleware.After)
+	}
+}
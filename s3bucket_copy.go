@@ -0,0 +1,145 @@
+package awskit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"code.olapie.com/log"
+	"code.olapie.com/sugar/v2/xerror"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// ErrPreconditionFailed is returned if a conditional operation's precondition doesn't hold
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// Copy copies srcKey to dstKey on the S3 side without transferring content through the client.
+// The source's metadata is copied, ACL is set to the bucket's ACL.
+func (s *S3Bucket) Copy(ctx context.Context, srcKey, dstKey string, optFns ...func(*s3.CopyObjectInput)) error {
+	return s.copyObject(ctx, s.newCopyObjectInput(srcKey, dstKey), dstKey, optFns)
+}
+
+// CopyFrom copies srcKey in bucket srcBucket to dstKey like Copy. srcKey isn't normalized by KeyCase.
+func (s *S3Bucket) CopyFrom(ctx context.Context, srcBucket, srcKey, dstKey string, optFns ...func(*s3.CopyObjectInput)) error {
+	input := s.newCopyObjectInput(srcKey, dstKey)
+	input.CopySource = aws.String(copySource(srcBucket, srcKey))
+	return s.copyObject(ctx, input, dstKey, optFns)
+}
+
+func (s *S3Bucket) copyObject(ctx context.Context, input *s3.CopyObjectInput, dstKey string, optFns []func(*s3.CopyObjectInput)) error {
+	for _, fn := range optFns {
+		fn(input)
+	}
+	_, err := s.client.CopyObject(ctx, input, s.clientOptions()...)
+	if err != nil {
+		if _, ok := xerror.CauseOf[*types.NoSuchKey](err); ok {
+			return xerror.NotFound("object %s doesn't exist", aws.ToString(input.CopySource))
+		}
+		return wrapS3Error(ctx, "CopyObject", dstKey, err)
+	}
+	return nil
+}
+
+// CopyIfMatch copies srcKey to dstKey only if srcKey's ETag still equals srcETag, otherwise returns ErrPreconditionFailed
+func (s *S3Bucket) CopyIfMatch(ctx context.Context, srcKey, dstKey, srcETag string, optFns ...func(*s3.CopyObjectInput)) error {
+	input := s.newCopyObjectInput(srcKey, dstKey)
+	input.CopySourceIfMatch = aws.String(srcETag)
+	for _, fn := range optFns {
+		fn(input)
+	}
+	_, err := s.client.CopyObject(ctx, input, s.clientOptions()...)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			return fmt.Errorf("%w: %s doesn't match etag %s", ErrPreconditionFailed, srcKey, srcETag)
+		}
+		return wrapS3Error(ctx, "CopyObject", dstKey, err)
+	}
+	return nil
+}
+
+type CopyOptions struct {
+	// Rollback deletes successful copies if any copy fails. It's best-effort, failures of deletion are ignored.
+	Rollback bool
+}
+
+// CopyToMany copies srcKey to each of dstKeys with at most concurrency goroutines.
+// Failures are returned as ObjectErrors keyed by destination key.
+func (s *S3Bucket) CopyToMany(ctx context.Context, srcKey string, dstKeys []string, concurrency int, optFns ...func(options *CopyOptions)) error {
+	options := new(CopyOptions)
+	for _, fn := range optFns {
+		fn(options)
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var mu sync.Mutex
+	var errs ObjectErrors
+	var copied []string
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, dstKey := range dstKeys {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(dstKey string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			_, err := s.client.CopyObject(ctx, s.newCopyObjectInput(srcKey, dstKey), s.clientOptions()...)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, &ObjectError{Key: dstKey, Err: wrapS3Error(ctx, "CopyObject", dstKey, err)})
+			} else {
+				copied = append(copied, dstKey)
+			}
+		}(dstKey)
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if options.Rollback && len(copied) != 0 {
+		if err := s.BatchDelete(ctx, copied); err != nil {
+			log.FromContext(ctx).Warn("Cannot roll back copies", log.Error(err))
+		}
+	}
+	return errs
+}
+
+func (s *S3Bucket) newCopyObjectInput(srcKey, dstKey string) *s3.CopyObjectInput {
+	return &s3.CopyObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(s.normalizeKey(dstKey)),
+		CopySource:   aws.String(copySource(s.bucket, s.normalizeKey(srcKey))),
+		ACL:          s.ACL,
+		CacheControl: s.cacheControlHeader(),
+
+		ServerSideEncryption: s.ServerSideEncryption,
+		SSEKMSKeyId:          s.kmsKeyID(),
+	}
+}
+
+// copySource returns url-encoded bucket/key which is required by CopySource
+func copySource(bucket, key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return bucket + "/" + strings.Join(segments, "/")
+}
+
+func isPreconditionFailed(err error) bool {
+	if apiErr, ok := xerror.CauseOf[smithy.APIError](err); ok {
+		return apiErr.ErrorCode() == "PreconditionFailed"
+	}
+	return false
+}
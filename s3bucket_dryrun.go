@@ -0,0 +1,66 @@
+package awskit
+
+import (
+	"context"
+
+	"code.olapie.com/log"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// dryRunUploadID is returned by CreateMultipartUpload in dry-run mode, so that following part uploads can be logged
+const dryRunUploadID = "dry-run"
+
+// dryRunOutput returns the operation name and a zero output of a mutating operation, or false for other operations
+func dryRunOutput(input any) (op string, key *string, output any, ok bool) {
+	switch in := input.(type) {
+	case *s3.PutObjectInput:
+		return "PutObject", in.Key, &s3.PutObjectOutput{}, true
+	case *s3.CopyObjectInput:
+		return "CopyObject", in.Key, &s3.CopyObjectOutput{}, true
+	case *s3.DeleteObjectInput:
+		return "DeleteObject", in.Key, &s3.DeleteObjectOutput{}, true
+	case *s3.DeleteObjectsInput:
+		// keys not reported in Errors are deleted
+		var key *string
+		if in.Delete != nil && len(in.Delete.Objects) != 0 {
+			key = in.Delete.Objects[0].Key
+		}
+		return "DeleteObjects", key, &s3.DeleteObjectsOutput{}, true
+	case *s3.CreateMultipartUploadInput:
+		return "CreateMultipartUpload", in.Key, &s3.CreateMultipartUploadOutput{UploadId: aws.String(dryRunUploadID)}, true
+	case *s3.UploadPartInput:
+		return "UploadPart", in.Key, &s3.UploadPartOutput{}, true
+	case *s3.CompleteMultipartUploadInput:
+		return "CompleteMultipartUpload", in.Key, &s3.CompleteMultipartUploadOutput{}, true
+	case *s3.AbortMultipartUploadInput:
+		return "AbortMultipartUpload", in.Key, &s3.AbortMultipartUploadOutput{}, true
+	case *s3.PutObjectTaggingInput:
+		return "PutObjectTagging", in.Key, &s3.PutObjectTaggingOutput{}, true
+	case *s3.DeleteObjectTaggingInput:
+		return "DeleteObjectTagging", in.Key, &s3.DeleteObjectTaggingOutput{}, true
+	case *s3.PutObjectAclInput:
+		return "PutObjectAcl", in.Key, &s3.PutObjectAclOutput{}, true
+	case *s3.RestoreObjectInput:
+		return "RestoreObject", in.Key, &s3.RestoreObjectOutput{}, true
+	default:
+		return "", nil, nil, false
+	}
+}
+
+func (s *S3Bucket) addDryRun(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AwskitDryRun", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		op, key, output, ok := dryRunOutput(in.Parameters)
+		if !ok {
+			return next.HandleInitialize(ctx, in)
+		}
+		log.FromContext(ctx).Info("Dry run",
+			log.String("bucket", s.bucket),
+			log.String("op", op),
+			log.String("key", aws.ToString(key)))
+		return middleware.InitializeOutput{Result: output}, middleware.Metadata{}, nil
+	}), middleware.Before)
+}
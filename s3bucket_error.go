@@ -0,0 +1,183 @@
+package awskit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.olapie.com/sugar/v2/xerror"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+)
+
+// ErrAccessDenied is matched by AccessDeniedError with errors.Is
+var ErrAccessDenied = errors.New("access denied")
+
+// AccessDeniedError is returned if S3 denies an operation, which usually means IAM policies are misconfigured.
+// Retrying doesn't help.
+type AccessDeniedError struct {
+	Op          string
+	Key         string
+	Attribution string
+
+	// RequestID and HostID are x-amz-request-id and x-amz-id-2 of the failed request, required by AWS support cases
+	RequestID string
+	HostID    string
+
+	Err error
+}
+
+func (e *AccessDeniedError) Error() string {
+	if e.Attribution != "" {
+		return fmt.Sprintf("s3.%s %s [%s]: access denied: %v", e.Op, e.Key, e.Attribution, e.Err)
+	}
+	return fmt.Sprintf("s3.%s %s: access denied: %v", e.Op, e.Key, e.Err)
+}
+
+func (e *AccessDeniedError) Unwrap() error {
+	return e.Err
+}
+
+func (e *AccessDeniedError) Is(target error) bool {
+	return target == ErrAccessDenied
+}
+
+// OperationError is returned if an S3 operation fails for reasons other than access denied
+type OperationError struct {
+	Op          string
+	Key         string
+	Attribution string
+
+	// RequestID and HostID are x-amz-request-id and x-amz-id-2 of the failed request, required by AWS support cases.
+	// They're empty if the request didn't reach S3.
+	RequestID string
+	HostID    string
+
+	Err error
+}
+
+func (e *OperationError) Error() string {
+	if e.Attribution != "" {
+		return fmt.Sprintf("s3.%s [%s]: %v", e.Op, e.Attribution, e.Err)
+	}
+	return fmt.Sprintf("s3.%s: %v", e.Op, e.Err)
+}
+
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// wrapS3Error annotates err returned by S3 operation op on key
+func wrapS3Error(ctx context.Context, op, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	attribution := GetAttribution(ctx)
+	requestID, hostID := GetS3RequestID(err)
+	if isAccessDenied(err) {
+		return &AccessDeniedError{
+			Op:          op,
+			Key:         key,
+			Attribution: attribution,
+			RequestID:   requestID,
+			HostID:      hostID,
+			Err:         err,
+		}
+	}
+	return &OperationError{
+		Op:          op,
+		Key:         key,
+		Attribution: attribution,
+		RequestID:   requestID,
+		HostID:      hostID,
+		Err:         err,
+	}
+}
+
+// GetS3RequestID returns x-amz-request-id and x-amz-id-2 of the S3 response that caused err, e.g. to log them
+func GetS3RequestID(err error) (requestID, hostID string) {
+	var reqErr interface{ ServiceRequestID() string }
+	if errors.As(err, &reqErr) {
+		requestID = reqErr.ServiceRequestID()
+	}
+	var hostErr interface{ ServiceHostID() string }
+	if errors.As(err, &hostErr) {
+		hostID = hostErr.ServiceHostID()
+	}
+	return requestID, hostID
+}
+
+func isAccessDenied(err error) bool {
+	if apiErr, ok := xerror.CauseOf[smithy.APIError](err); ok && apiErr.ErrorCode() == "AccessDenied" {
+		return true
+	}
+	if respErr, ok := xerror.CauseOf[*awshttp.ResponseError](err); ok && respErr.HTTPStatusCode() == http.StatusForbidden {
+		return true
+	}
+	return false
+}
+
+// ObjectError is an error occurred on a specific object in a bulk operation
+type ObjectError struct {
+	Key string
+	Err error
+}
+
+func (e *ObjectError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Key, e.Err)
+}
+
+func (e *ObjectError) Unwrap() error {
+	return e.Err
+}
+
+// ObjectErrors aggregates per-object errors of a bulk operation
+type ObjectErrors []*ObjectError
+
+func (e ObjectErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, oe := range e {
+		msgs[i] = oe.Error()
+	}
+	return fmt.Sprintf("%d objects failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// BatchDeleteError is returned by BatchDelete and DeleteOlderThan if some keys can't be deleted.
+// Errors of Failures implement smithy.APIError with S3's error code, or are AccessDeniedError.
+type BatchDeleteError struct {
+	Failures []*ObjectError
+}
+
+func (e *BatchDeleteError) Error() string {
+	keys := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		keys[i] = f.Key
+	}
+	return fmt.Sprintf("some ids cannot be deleted: %v", keys)
+}
+
+// Is reports whether any failure matches target, e.g. errors.Is(err, ErrAccessDenied)
+func (e *BatchDeleteError) Is(target error) bool {
+	for _, f := range e.Failures {
+		if errors.Is(f.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// newBatchDeleteError returns failed keys of result as BatchDeleteError, or nil if all keys are deleted
+func newBatchDeleteError(result *BatchResult[struct{}]) error {
+	var failures []*ObjectError
+	for _, item := range result.Items {
+		if item.Err != nil {
+			failures = append(failures, &ObjectError{Key: item.Key, Err: item.Err})
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return &BatchDeleteError{Failures: failures}
+}
@@ -0,0 +1,43 @@
+//go:build go1.23
+
+package awskit
+
+import (
+	"context"
+	"iter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Objects lazily pages through objects under prefix, e.g.
+//
+//	for obj, err := range bucket.Objects(ctx, "photos/") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// A listing failure is yielded as the last element. No more pages are requested once the loop breaks.
+func (s *S3Bucket) Objects(ctx context.Context, prefix string) iter.Seq2[*ObjectInfo, error] {
+	return func(yield func(*ObjectInfo, error) bool) {
+		input := &s3.ListObjectsV2Input{
+			Bucket: aws.String(s.bucket),
+			Prefix: aws.String(s.normalizeKey(prefix)),
+		}
+		paginator := s3.NewListObjectsV2Paginator(s.client, input)
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx, s.clientOptions()...)
+			if err != nil {
+				yield(nil, wrapS3Error(ctx, "ListObjectsV2", prefix, err))
+				return
+			}
+			for _, obj := range output.Contents {
+				if !yield(newObjectInfo(obj), nil) {
+					return
+				}
+			}
+		}
+	}
+}
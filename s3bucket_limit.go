@@ -0,0 +1,43 @@
+package awskit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/smithy-go/middleware"
+)
+
+// concurrencyLimiter bounds in-flight operations of an S3Bucket and its copies.
+// Its capacity is fixed by S3Bucket.MaxConcurrency at the first operation.
+type concurrencyLimiter struct {
+	once sync.Once
+	sem  chan struct{}
+}
+
+func (l *concurrencyLimiter) acquire(ctx context.Context, n int) error {
+	l.once.Do(func() {
+		l.sem = make(chan struct{}, n)
+	})
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.sem
+}
+
+func (s *S3Bucket) addConcurrencyLimit(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AwskitConcurrencyLimit", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		if err := s.limiter.acquire(ctx, s.MaxConcurrency); err != nil {
+			return middleware.InitializeOutput{}, middleware.Metadata{}, err
+		}
+		defer s.limiter.release()
+		return next.HandleInitialize(ctx, in)
+	}), middleware.Before)
+}
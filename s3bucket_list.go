@@ -0,0 +1,185 @@
+package awskit
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"code.olapie.com/sugar/v2/xerror"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// forEachObject pages through objects under prefix and calls fn for each of them until fn returns an error
+func (s *S3Bucket) forEachObject(ctx context.Context, prefix string, fn func(obj types.Object) error) error {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.normalizeKey(prefix)),
+	}
+	paginator := s3.NewListObjectsV2Paginator(s.client, input)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx, s.clientOptions()...)
+		if err != nil {
+			return wrapS3Error(ctx, "ListObjectsV2", prefix, err)
+		}
+		for _, obj := range output.Contents {
+			if err = fn(obj); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type ListOptions struct {
+	// Limit stops listing after Limit objects if positive
+	Limit int
+
+	// StartAfter lists objects whose keys are after StartAfter in lexicographical order
+	StartAfter string
+}
+
+type ListOption func(options *ListOptions)
+
+// List returns all objects under prefix, paging through them. Use ListPage or ListPages for large prefixes.
+// It returns an empty slice if there are no objects.
+func (s *S3Bucket) List(ctx context.Context, prefix string, opts ...ListOption) ([]*ObjectInfo, error) {
+	options := new(ListOptions)
+	for _, fn := range opts {
+		fn(options)
+	}
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.normalizeKey(prefix)),
+	}
+	if options.StartAfter != "" {
+		input.StartAfter = aws.String(s.normalizeKey(options.StartAfter))
+	}
+
+	objects := []*ObjectInfo{}
+	paginator := s3.NewListObjectsV2Paginator(s.client, input)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx, s.clientOptions()...)
+		if err != nil {
+			return nil, wrapS3Error(ctx, "ListObjectsV2", prefix, err)
+		}
+		for _, obj := range output.Contents {
+			if options.Limit > 0 && len(objects) >= options.Limit {
+				return objects, nil
+			}
+			objects = append(objects, newObjectInfo(obj))
+		}
+	}
+	return objects, nil
+}
+
+// ListPage returns up to limit objects under prefix after token, which is the nextToken of the previous page,
+// or empty for the first page. nextToken is empty on the last page. limit is capped at 1000 by S3.
+func (s *S3Bucket) ListPage(ctx context.Context, prefix, token string, limit int32) ([]*ObjectInfo, string, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(s.normalizeKey(prefix)),
+		MaxKeys: limit,
+	}
+	if token != "" {
+		input.ContinuationToken = aws.String(token)
+	}
+	output, err := s.client.ListObjectsV2(ctx, input, s.clientOptions()...)
+	if err != nil {
+		return nil, "", wrapS3Error(ctx, "ListObjectsV2", prefix, err)
+	}
+	objects := make([]*ObjectInfo, len(output.Contents))
+	for i, obj := range output.Contents {
+		objects[i] = newObjectInfo(obj)
+	}
+	if !output.IsTruncated {
+		return objects, "", nil
+	}
+	return objects, aws.ToString(output.NextContinuationToken), nil
+}
+
+// ListPages pages through objects under prefix and calls fn with each page until fn returns an error
+func (s *S3Bucket) ListPages(ctx context.Context, prefix string, fn func(objects []*ObjectInfo) error) error {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.normalizeKey(prefix)),
+	}
+	paginator := s3.NewListObjectsV2Paginator(s.client, input)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx, s.clientOptions()...)
+		if err != nil {
+			return wrapS3Error(ctx, "ListObjectsV2", prefix, err)
+		}
+		objects := make([]*ObjectInfo, len(output.Contents))
+		for i, obj := range output.Contents {
+			objects[i] = newObjectInfo(obj)
+		}
+		if err = fn(objects); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListModifiedBetween returns objects under prefix whose LastModified is in [from, to), and a cursor to resume from,
+// which is empty when the listing is exhausted. S3 can't filter by time, so objects are filtered while paging
+// until some match or the listing ends.
+func (s *S3Bucket) ListModifiedBetween(ctx context.Context, prefix string, from, to time.Time, cursor string) ([]*ObjectInfo, string, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.normalizeKey(prefix)),
+	}
+	if cursor != "" {
+		token, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, "", xerror.BadRequest("invalid cursor")
+		}
+		input.ContinuationToken = aws.String(string(token))
+	}
+
+	for {
+		output, err := s.client.ListObjectsV2(ctx, input, s.clientOptions()...)
+		if err != nil {
+			return nil, "", wrapS3Error(ctx, "ListObjectsV2", prefix, err)
+		}
+
+		var objects []*ObjectInfo
+		for _, obj := range output.Contents {
+			if t := aws.ToTime(obj.LastModified); !t.Before(from) && t.Before(to) {
+				objects = append(objects, newObjectInfo(obj))
+			}
+		}
+
+		if !output.IsTruncated || output.NextContinuationToken == nil {
+			return objects, "", nil
+		}
+		if len(objects) != 0 {
+			return objects, base64.RawURLEncoding.EncodeToString([]byte(*output.NextContinuationToken)), nil
+		}
+		input.ContinuationToken = output.NextContinuationToken
+	}
+}
+
+// PrefixSize returns the total size in bytes of objects under prefix. It lists all objects, so it's slow on large prefixes.
+func (s *S3Bucket) PrefixSize(ctx context.Context, prefix string) (int64, error) {
+	var size int64
+	err := s.forEachObject(ctx, prefix, func(obj types.Object) error {
+		size += obj.Size
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func newObjectInfo(obj types.Object) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          aws.ToString(obj.Key),
+		Size:         obj.Size,
+		ETag:         aws.ToString(obj.ETag),
+		LastModified: aws.ToTime(obj.LastModified),
+	}
+}
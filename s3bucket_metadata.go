@@ -0,0 +1,209 @@
+package awskit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	maxObjectTags     = 10
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+)
+
+// AuditRecord describes a change of an object's metadata, tags or ACL
+type AuditRecord struct {
+	Op          string
+	Key         string
+	Before      map[string]string
+	After       map[string]string
+	Time        time.Time
+	Attribution string
+}
+
+// AuditSink receives audit records, e.g. to write them into a compliance log
+type AuditSink func(ctx context.Context, record *AuditRecord)
+
+// UpdateMetadata merges metadata into the object's user metadata by copying the object onto itself.
+// Other headers like Content-Type are preserved, but the ACL isn't: like any copy, the object gets ACL,
+// or private if ACL is empty, so grants set by SetACL must be set again. Buckets with ACLs disabled aren't affected.
+func (s *S3Bucket) UpdateMetadata(ctx context.Context, key string, metadata map[string]string) error {
+	head, err := s.GetHeadObject(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	merged := make(map[string]string, len(head.Metadata)+len(metadata))
+	for k, v := range head.Metadata {
+		merged[k] = v
+	}
+	for k, v := range metadata {
+		merged[strings.ToLower(k)] = v
+	}
+
+	input := s.newCopyObjectInput(key, key)
+	input.MetadataDirective = types.MetadataDirectiveReplace
+	input.Metadata = merged
+	input.ContentType = head.ContentType
+	input.ContentEncoding = head.ContentEncoding
+	input.ContentLanguage = head.ContentLanguage
+	input.ContentDisposition = head.ContentDisposition
+	input.CacheControl = head.CacheControl
+	input.StorageClass = types.StorageClass(head.StorageClass)
+	_, err = s.client.CopyObject(ctx, input, s.clientOptions()...)
+	if err != nil {
+		return wrapS3Error(ctx, "CopyObject", key, err)
+	}
+	s.audit(ctx, "UpdateMetadata", key, head.Metadata, merged)
+	return nil
+}
+
+// GetTags returns the object's tags
+func (s *S3Bucket) GetTags(ctx context.Context, key string) (map[string]string, error) {
+	output, err := s.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.normalizeKey(key)),
+	}, s.clientOptions()...)
+	if err != nil {
+		return nil, wrapS3Error(ctx, "GetObjectTagging", key, err)
+	}
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// PutTags replaces the object's tags. S3 allows up to 10 tags per object,
+// with keys of up to 128 characters and values of up to 256 characters.
+func (s *S3Bucket) PutTags(ctx context.Context, key string, tags map[string]string) error {
+	if err := validateTags(tags); err != nil {
+		return err
+	}
+	var before map[string]string
+	if s.AuditSink != nil && s.AuditBefore {
+		var err error
+		if before, err = s.GetTags(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	tagging := &types.Tagging{
+		TagSet: make([]types.Tag, 0, len(tags)),
+	}
+	for k, v := range tags {
+		tagging.TagSet = append(tagging.TagSet, types.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(v),
+		})
+	}
+	_, err := s.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(s.bucket),
+		Key:     aws.String(s.normalizeKey(key)),
+		Tagging: tagging,
+	}, s.clientOptions()...)
+	if err != nil {
+		return wrapS3Error(ctx, "PutObjectTagging", key, err)
+	}
+	s.audit(ctx, "PutTags", key, before, tags)
+	return nil
+}
+
+// validateTags checks tags against S3's limits, so that invalid tags fail before uploading content
+func validateTags(tags map[string]string) error {
+	if len(tags) > maxObjectTags {
+		return fmt.Errorf("too many tags: %d > %d", len(tags), maxObjectTags)
+	}
+	for k, v := range tags {
+		if k == "" {
+			return fmt.Errorf("empty tag key")
+		}
+		if n := utf8.RuneCountInString(k); n > maxTagKeyLength {
+			return fmt.Errorf("tag key %s is longer than %d characters", k, maxTagKeyLength)
+		}
+		if n := utf8.RuneCountInString(v); n > maxTagValueLength {
+			return fmt.Errorf("value of tag %s is longer than %d characters", k, maxTagValueLength)
+		}
+	}
+	return nil
+}
+
+// encodeTags encodes tags as URL query parameters which header x-amz-tagging requires
+func encodeTags(tags map[string]string) string {
+	values := make(url.Values, len(tags))
+	for k, v := range tags {
+		values.Set(k, v)
+	}
+	// url.Values encodes space as +, which S3 would keep as is
+	return strings.ReplaceAll(values.Encode(), "+", "%20")
+}
+
+// SetACL sets the object's canned ACL. Audit records describe ACLs as grantee to permissions.
+func (s *S3Bucket) SetACL(ctx context.Context, key string, acl types.ObjectCannedACL) error {
+	var before map[string]string
+	if s.AuditSink != nil && s.AuditBefore {
+		var err error
+		if before, err = s.getGrants(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.client.PutObjectAcl(ctx, &s3.PutObjectAclInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.normalizeKey(key)),
+		ACL:    acl,
+	}, s.clientOptions()...)
+	if err != nil {
+		return wrapS3Error(ctx, "PutObjectAcl", key, err)
+	}
+	s.audit(ctx, "SetACL", key, before, map[string]string{"acl": string(acl)})
+	return nil
+}
+
+func (s *S3Bucket) getGrants(ctx context.Context, key string) (map[string]string, error) {
+	output, err := s.client.GetObjectAcl(ctx, &s3.GetObjectAclInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.normalizeKey(key)),
+	}, s.clientOptions()...)
+	if err != nil {
+		return nil, wrapS3Error(ctx, "GetObjectAcl", key, err)
+	}
+	grants := make(map[string]string, len(output.Grants))
+	for _, g := range output.Grants {
+		if g.Grantee == nil {
+			continue
+		}
+		grantee := aws.ToString(g.Grantee.ID)
+		if grantee == "" {
+			grantee = aws.ToString(g.Grantee.URI)
+		}
+		if grants[grantee] != "" {
+			grants[grantee] += ","
+		}
+		grants[grantee] += string(g.Permission)
+	}
+	return grants, nil
+}
+
+// audit sends a record to AuditSink, except in dry-run mode where nothing is changed
+func (s *S3Bucket) audit(ctx context.Context, op, key string, before, after map[string]string) {
+	if s.AuditSink == nil || s.DryRun {
+		return
+	}
+	s.AuditSink(ctx, &AuditRecord{
+		Op:          op,
+		Key:         key,
+		Before:      before,
+		After:       after,
+		Time:        time.Now(),
+		Attribution: GetAttribution(ctx),
+	})
+}
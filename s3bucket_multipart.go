@@ -0,0 +1,189 @@
+package awskit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	defaultPartSize        = 8 << 20
+	maxUploadParts         = 10000
+	abortUploadTimeout     = 30 * time.Second
+	defaultPartConcurrency = 4
+)
+
+type MultipartOptions struct {
+	// PartSize is the size of each part but the last. Defaults to 8MB.
+	// S3 rejects parts smaller than 5MB except the last one when the upload is completed.
+	PartSize int64
+
+	// Concurrency is the max number of parts uploaded at the same time. Defaults to 4.
+	// Up to Concurrency parts are buffered in memory.
+	Concurrency int
+}
+
+type MultipartOption func(options *MultipartOptions)
+
+// PutMultipart uploads r with a multipart upload, which is required for objects larger than 5GB
+// and faster for objects of hundreds of MB. Content type is detected from the first part.
+// The upload is aborted on any error so that incomplete parts aren't left behind and billed.
+func (s *S3Bucket) PutMultipart(ctx context.Context, key string, r io.Reader, opts ...MultipartOption) error {
+	options := &MultipartOptions{
+		PartSize:    defaultPartSize,
+		Concurrency: defaultPartConcurrency,
+	}
+	for _, fn := range opts {
+		fn(options)
+	}
+	if options.PartSize <= 0 {
+		return fmt.Errorf("invalid part size %d", options.PartSize)
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+
+	first, last, err := readPart(r, options.PartSize)
+	if err != nil {
+		return err
+	}
+	uploadID, err := s.CreateMultipartUpload(ctx, key, func(input *s3.CreateMultipartUploadInput) {
+		input.ContentType = aws.String(http.DetectContentType(first))
+		if s.ContentLanguage != "" {
+			input.ContentLanguage = aws.String(s.ContentLanguage)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	parts, err := s.uploadParts(ctx, key, uploadID, r, first, last, options)
+	if err == nil {
+		_, err = s.CompleteMultipartUpload(ctx, key, uploadID, parts)
+	}
+	if err != nil {
+		// ctx may be done already
+		abortCtx, cancel := context.WithTimeout(context.Background(), abortUploadTimeout)
+		defer cancel()
+		if abortErr := s.AbortMultipartUpload(abortCtx, key, uploadID); abortErr != nil {
+			return fmt.Errorf("%w, abort upload %s: %v", err, uploadID, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *S3Bucket) uploadParts(ctx context.Context, key, uploadID string, r io.Reader, first []byte, last bool,
+	options *MultipartOptions) ([]types.CompletedPart, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		parts    []types.CompletedPart
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+
+	// sem bounds both in-flight requests and buffered parts
+	sem := make(chan struct{}, options.Concurrency)
+	content := first
+	for number := int32(1); ; number++ {
+		if number > maxUploadParts {
+			fail(fmt.Errorf("more than %d parts of %d bytes", maxUploadParts, options.PartSize))
+			break
+		}
+		mu.Lock()
+		parts = append(parts, types.CompletedPart{PartNumber: number})
+		mu.Unlock()
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			fail(err)
+			break
+		}
+
+		wg.Add(1)
+		go func(number int32, content []byte) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			etag, err := s.uploadPart(ctx, key, uploadID, number, content)
+			if err != nil {
+				fail(err)
+				return
+			}
+			mu.Lock()
+			parts[number-1].ETag = aws.String(etag)
+			mu.Unlock()
+		}(number, content)
+
+		if last {
+			break
+		}
+		var err error
+		content, last, err = readPart(r, options.PartSize)
+		if err != nil {
+			fail(err)
+			break
+		}
+		if len(content) == 0 {
+			break
+		}
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return parts, nil
+}
+
+func (s *S3Bucket) uploadPart(ctx context.Context, key, uploadID string, number int32, content []byte) (string, error) {
+	output, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(s.normalizeKey(key)),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    number,
+		Body:          bytes.NewReader(content),
+		ContentLength: int64(len(content)),
+	}, s.clientOptions()...)
+	if err != nil {
+		return "", wrapS3Error(ctx, "UploadPart", key, err)
+	}
+	return aws.ToString(output.ETag), nil
+}
+
+// readPart reads up to size bytes. last is true if r is drained.
+func readPart(r io.Reader, size int64) (content []byte, last bool, err error) {
+	content = make([]byte, size)
+	n, err := io.ReadFull(r, content)
+	switch {
+	case err == nil:
+		return content, false, nil
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return content[:n], true, nil
+	default:
+		return nil, false, fmt.Errorf("read: %w", err)
+	}
+}
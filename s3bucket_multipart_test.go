@@ -0,0 +1,46 @@
+package awskit_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"code.olapie.com/awskit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3_PutMultipart(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	content := bytes.Repeat([]byte("0123456789"), 250)
+	err := bucket.PutMultipart(ctx, "large", bytes.NewReader(content), func(options *awskit.MultipartOptions) {
+		options.PartSize = 1000
+		options.Concurrency = 2
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, fake.Requests["UploadPart"])
+	require.Equal(t, 1, fake.Requests["CompleteMultipartUpload"])
+
+	stored, ok := fake.Object("large")
+	require.True(t, ok)
+	require.Equal(t, content, stored)
+}
+
+func TestS3_PutMultipart_Abort(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	fake.FailPart = 2
+	content := bytes.Repeat([]byte("0123456789"), 250)
+	err := bucket.PutMultipart(ctx, "large", bytes.NewReader(content), func(options *awskit.MultipartOptions) {
+		options.PartSize = 1000
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, fake.Requests["AbortMultipartUpload"])
+	require.Zero(t, fake.PendingUploads())
+
+	_, ok := fake.Object("large")
+	require.False(t, ok)
+}
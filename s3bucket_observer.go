@@ -0,0 +1,79 @@
+package awskit
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+type attributionKey struct{}
+
+// WithAttribution tags S3 operations performed with ctx, e.g. with tenant id or feature name, for cost allocation and debugging.
+// The attribution is passed to S3Bucket.Observer and included in errors.
+func WithAttribution(ctx context.Context, attribution string) context.Context {
+	return context.WithValue(ctx, attributionKey{}, attribution)
+}
+
+func GetAttribution(ctx context.Context) string {
+	a, _ := ctx.Value(attributionKey{}).(string)
+	return a
+}
+
+// S3Operation describes a finished S3 operation
+type S3Operation struct {
+	Name        string
+	Bucket      string
+	Key         string
+	Attribution string
+	Duration    time.Duration
+
+	// RequestID and HostID are x-amz-request-id and x-amz-id-2 of the response if S3 responded
+	RequestID string
+	HostID    string
+
+	Err error
+}
+
+// S3Observer is called after each S3 operation, e.g. to log or collect metrics
+type S3Observer func(ctx context.Context, op *S3Operation)
+
+func (s *S3Bucket) addObserver(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AwskitObserver", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		start := time.Now()
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		op := &S3Operation{
+			Name:        awsmiddleware.GetOperationName(ctx),
+			Bucket:      s.bucket,
+			Key:         inputKey(in.Parameters),
+			Attribution: GetAttribution(ctx),
+			Duration:    time.Since(start),
+			Err:         err,
+		}
+		if err != nil {
+			op.RequestID, op.HostID = GetS3RequestID(err)
+		} else {
+			op.RequestID, _ = awsmiddleware.GetRequestIDMetadata(metadata)
+			op.HostID, _ = s3.GetHostIDMetadata(metadata)
+		}
+		s.Observer(ctx, op)
+		return out, metadata, err
+	}), middleware.After)
+}
+
+// inputKey returns field Key of operation input if it exists
+func inputKey(input any) string {
+	v := reflect.Indirect(reflect.ValueOf(input))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if key, ok := v.FieldByName("Key").Interface().(*string); ok && key != nil {
+		return *key
+	}
+	return ""
+}
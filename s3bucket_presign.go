@@ -0,0 +1,220 @@
+package awskit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awssigner "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// maxPresignExpiry is the longest validity of a SigV4 presigned URL
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// skewedPresigner backdates the signing time so that URLs are already valid for clients whose clocks are behind
+type skewedPresigner struct {
+	s3.HTTPPresignerV4
+	skew time.Duration
+}
+
+func (p *skewedPresigner) PresignHTTP(ctx context.Context, credentials aws.Credentials, r *http.Request,
+	payloadHash string, service string, region string, signingTime time.Time,
+	optFns ...func(*awssigner.SignerOptions)) (string, http.Header, error) {
+	return p.HTTPPresignerV4.PresignHTTP(ctx, credentials, r, payloadHash, service, region, signingTime.Add(-p.skew), optFns...)
+}
+
+// presignOptions makes a presigned URL valid from PresignSkew ago until ttl later
+func (s *S3Bucket) presignOptions(ttl time.Duration) func(options *s3.PresignOptions) {
+	return func(options *s3.PresignOptions) {
+		options.Expires = ttl
+		if s.PresignSkew <= 0 {
+			return
+		}
+		options.Expires += s.PresignSkew
+		if options.Expires > maxPresignExpiry {
+			options.Expires = maxPresignExpiry
+		}
+		options.Presigner = &skewedPresigner{
+			HTTPPresignerV4: options.Presigner,
+			skew:            s.PresignSkew,
+		}
+	}
+}
+
+// PresignGet returns a URL to download the object without credentials until expires later.
+// expires is clamped to 7 days, the longest validity SigV4 allows.
+func (s *S3Bucket) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	req, err := s.PreSignGet(ctx, key, clampPresignExpiry(expires))
+	if err != nil {
+		return "", fmt.Errorf("presign get %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// PresignPut returns a URL to upload the object without credentials until expires later.
+// The bucket's ACL and CacheControl are signed into the URL so that uploads match Put,
+// hence clients must send the same x-amz-acl and Cache-Control headers. expires is clamped to 7 days.
+func (s *S3Bucket) PresignPut(ctx context.Context, key string, expires time.Duration) (string, error) {
+	req, err := s.PreSignPut(ctx, key, clampPresignExpiry(expires), func(input *s3.PutObjectInput) {
+		input.ACL = s.ACL
+		input.CacheControl = s.cacheControlHeader()
+	})
+	if err != nil {
+		return "", fmt.Errorf("presign put %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// PresignedPost is an upload form. Browsers must POST multipart/form-data to URL with Fields,
+// optionally a Content-Type field, followed by the file field which must be the last.
+type PresignedPost struct {
+	URL    string
+	Fields map[string]string
+}
+
+// PresignPost returns a form to upload the object without credentials until expires later. Unlike PresignPut,
+// the signed policy makes S3 reject files smaller than minSize or larger than maxSize bytes.
+// The bucket's ACL, CacheControl and ServerSideEncryption are included in the form like Put. expires is clamped to 7 days.
+func (s *S3Bucket) PresignPost(ctx context.Context, key string, expires time.Duration, minSize, maxSize int64) (*PresignedPost, error) {
+	if minSize < 0 || maxSize < minSize {
+		return nil, fmt.Errorf("invalid content length range %d-%d", minSize, maxSize)
+	}
+
+	// presign a GET to reuse the client's credentials, region and endpoint resolution
+	signer := new(postPolicySigner)
+	_, err := s.presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.normalizeKey(key)),
+	}, func(options *s3.PresignOptions) {
+		options.Presigner = signer
+	})
+	if err != nil {
+		return nil, fmt.Errorf("presign post %s: %w", key, err)
+	}
+	if signer.url == nil {
+		return nil, fmt.Errorf("presign post %s: missing credentials", key)
+	}
+
+	fields := map[string]string{
+		"key":              s.normalizeKey(key),
+		"x-amz-algorithm":  "AWS4-HMAC-SHA256",
+		"x-amz-credential": signer.credentials.AccessKeyID + "/" + signer.scope(),
+		"x-amz-date":       signer.signingTime.Format("20060102T150405Z"),
+	}
+	if signer.credentials.SessionToken != "" {
+		fields["x-amz-security-token"] = signer.credentials.SessionToken
+	}
+	if s.ACL != "" {
+		fields["acl"] = string(s.ACL)
+	}
+	if s.CacheControl != "" {
+		fields["Cache-Control"] = s.CacheControl
+	}
+	if s.ServerSideEncryption != "" {
+		fields["x-amz-server-side-encryption"] = string(s.ServerSideEncryption)
+	}
+	if s.KMSKeyID != "" {
+		fields["x-amz-server-side-encryption-aws-kms-key-id"] = s.KMSKeyID
+	}
+
+	conditions := []any{
+		map[string]string{"bucket": s.bucket},
+		[]any{"content-length-range", minSize, maxSize},
+		[]any{"starts-with", "$Content-Type", ""},
+	}
+	for k, v := range fields {
+		conditions = append(conditions, map[string]string{k: v})
+	}
+	policy, err := json.Marshal(map[string]any{
+		"expiration": signer.signingTime.Add(clampPresignExpiry(expires)).Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+	fields["policy"] = base64.StdEncoding.EncodeToString(policy)
+	fields["x-amz-signature"] = signer.sign(fields["policy"])
+
+	// the presigned GET's path is the object's path, i.e. /key or /bucket/key
+	u := *signer.url
+	u.Path = strings.TrimSuffix(u.Path, s.normalizeKey(key))
+	u.RawPath = ""
+	u.RawQuery = ""
+	return &PresignedPost{URL: u.String(), Fields: fields}, nil
+}
+
+// postPolicySigner captures what the SDK would presign with, instead of presigning a request
+type postPolicySigner struct {
+	credentials aws.Credentials
+	region      string
+	signingTime time.Time
+	url         *url.URL
+}
+
+var _ s3.HTTPPresignerV4 = (*postPolicySigner)(nil)
+
+func (p *postPolicySigner) PresignHTTP(ctx context.Context, credentials aws.Credentials, r *http.Request,
+	payloadHash string, service string, region string, signingTime time.Time,
+	optFns ...func(*awssigner.SignerOptions)) (string, http.Header, error) {
+	if service != "s3" {
+		return "", nil, errors.New("unsupported service " + service)
+	}
+	p.credentials = credentials
+	p.region = region
+	p.signingTime = signingTime.UTC()
+	p.url = r.URL
+	return r.URL.String(), nil, nil
+}
+
+func (p *postPolicySigner) scope() string {
+	return p.signingTime.Format("20060102") + "/" + p.region + "/s3/aws4_request"
+}
+
+// sign returns SigV4 signature of a base64 encoded policy
+func (p *postPolicySigner) sign(policy string) string {
+	key := []byte("AWS4" + p.credentials.SecretAccessKey)
+	for _, s := range []string{p.signingTime.Format("20060102"), p.region, "s3", "aws4_request", policy} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(s))
+		key = mac.Sum(nil)
+	}
+	return hex.EncodeToString(key)
+}
+
+func clampPresignExpiry(expires time.Duration) time.Duration {
+	if expires > maxPresignExpiry {
+		return maxPresignExpiry
+	}
+	return expires
+}
+
+// PresignExpiry returns when a presigned request expires, i.e. its signing time plus X-Amz-Expires,
+// so that clients know when to refresh it
+func PresignExpiry(req *awssigner.PresignedHTTPRequest) (time.Time, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("url.Parse: %w", err)
+	}
+	query := u.Query()
+	signedAt, err := time.Parse("20060102T150405Z", query.Get("X-Amz-Date"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid X-Amz-Date: %w", err)
+	}
+	seconds, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid X-Amz-Expires: %w", err)
+	}
+	return signedAt.Add(time.Duration(seconds) * time.Second), nil
+}
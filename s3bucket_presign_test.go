@@ -0,0 +1,43 @@
+package awskit_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"code.olapie.com/awskit"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3_PresignPost(t *testing.T) {
+	client := s3.NewFromConfig(aws.Config{
+		Region: "us-west-2",
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}, nil
+		}),
+	})
+	bucket := awskit.NewS3Bucket("uploads", client)
+	post, err := bucket.PresignPost(context.Background(), "avatars/1.png", time.Minute, 1, 1<<20)
+	require.NoError(t, err)
+	require.Equal(t, "https://uploads.s3.us-west-2.amazonaws.com/", post.URL)
+	require.Equal(t, "avatars/1.png", post.Fields["key"])
+	require.Equal(t, "private", post.Fields["acl"])
+	require.Len(t, post.Fields["x-amz-signature"], 64)
+
+	data, err := base64.StdEncoding.DecodeString(post.Fields["policy"])
+	require.NoError(t, err)
+	var policy struct {
+		Expiration string
+		Conditions []any
+	}
+	require.NoError(t, json.Unmarshal(data, &policy))
+	require.Contains(t, policy.Conditions, []any{"content-length-range", float64(1), float64(1 << 20)})
+	require.Contains(t, policy.Conditions, map[string]any{"key": "avatars/1.png"})
+
+	_, err = bucket.PresignPost(context.Background(), "avatars/1.png", time.Minute, 10, 1)
+	require.Error(t, err)
+}
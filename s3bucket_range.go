@@ -0,0 +1,135 @@
+package awskit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.olapie.com/sugar/v2/xerror"
+	"code.olapie.com/sugar/v2/xruntime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+var ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// ObjectRange is a byte range of an object. Start and End are inclusive offsets, Size is the total object size.
+type ObjectRange struct {
+	Content      []byte
+	Start        int64
+	End          int64
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// GetRange reads bytes [start, end] of an object. An end of -1 means to the end of the object.
+func (s *S3Bucket) GetRange(ctx context.Context, key string, start, end int64, optFns ...func(input *s3.GetObjectInput)) ([]byte, error) {
+	r, err := s.GetRangeInfo(ctx, key, start, end, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	return r.Content, nil
+}
+
+// GetRangeInfo is like GetRange but also returns the actual range and the total object size parsed from Content-Range.
+// Range (0, -1) reads the whole object without sending Range, which S3 rejects for empty objects,
+// so it returns empty content rather than ErrRangeNotSatisfiable for them.
+// Other ranges return ErrRangeNotSatisfiable if start is beyond the object's end.
+// Content is read within MaxObjectSize.
+func (s *S3Bucket) GetRangeInfo(ctx context.Context, key string, start, end int64, optFns ...func(input *s3.GetObjectInput)) (*ObjectRange, error) {
+	if start < 0 || (end != -1 && end < start) {
+		return nil, fmt.Errorf("invalid range %d-%d", start, end)
+	}
+	if start == 0 && end == -1 {
+		return s.getRange(ctx, key, "", optFns)
+	}
+	return s.getRange(ctx, key, httpRange(start, end), optFns)
+}
+
+// GetSuffixRangeInfo reads the last n bytes of an object, or the whole object if it's shorter than n,
+// e.g. for a Range header like bytes=-500. It returns ErrRangeNotSatisfiable if the object is empty.
+func (s *S3Bucket) GetSuffixRangeInfo(ctx context.Context, key string, n int64, optFns ...func(input *s3.GetObjectInput)) (*ObjectRange, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid suffix length %d", n)
+	}
+	return s.getRange(ctx, key, fmt.Sprintf("bytes=-%d", n), optFns)
+}
+
+// getRange reads the object with header Range rng, or without Range if rng is empty
+func (s *S3Bucket) getRange(ctx context.Context, key, rng string, optFns []func(input *s3.GetObjectInput)) (*ObjectRange, error) {
+	fns := make([]func(*s3.GetObjectInput), 0, len(optFns)+1)
+	if rng != "" {
+		fns = append(fns, func(input *s3.GetObjectInput) {
+			input.Range = aws.String(rng)
+		})
+	}
+	output, err := s.getObject(ctx, key, append(fns, optFns...)...)
+	if err != nil {
+		if apiErr, ok := xerror.CauseOf[smithy.APIError](err); ok && apiErr.ErrorCode() == "InvalidRange" {
+			return nil, fmt.Errorf("%w: %s %s", ErrRangeNotSatisfiable, key, rng)
+		}
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	content, err := s.readContent(key, output)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ObjectRange{
+		Content:      content,
+		Start:        0,
+		End:          int64(len(content)) - 1,
+		Size:         int64(len(content)),
+		ContentType:  xruntime.Dereference(output.ContentType),
+		ETag:         xruntime.Dereference(output.ETag),
+		LastModified: xruntime.Dereference(output.LastModified),
+	}
+	// Content-Range is absent if Range wasn't sent or S3 ignored it and returned the whole object
+	if cr := xruntime.Dereference(output.ContentRange); cr != "" {
+		if r.Start, r.End, r.Size, err = parseContentRange(cr); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func httpRange(start, end int64) string {
+	if end < 0 {
+		return fmt.Sprintf("bytes=%d-", start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", start, end)
+}
+
+// parseContentRange parses a Content-Range value like "bytes 0-99/1000"
+func parseContentRange(s string) (start, end, size int64, err error) {
+	spec := strings.TrimPrefix(s, "bytes ")
+	rng, total, ok := strings.Cut(spec, "/")
+	if spec == s || !ok {
+		return 0, 0, 0, fmt.Errorf("invalid content range %q", s)
+	}
+	first, last, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("invalid content range %q", s)
+	}
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid content range %q: %w", s, err)
+	}
+	if end, err = strconv.ParseInt(last, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid content range %q: %w", s, err)
+	}
+	if total == "*" {
+		return start, end, -1, nil
+	}
+	if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid content range %q: %w", s, err)
+	}
+	return start, end, size, nil
+}
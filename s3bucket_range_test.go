@@ -0,0 +1,59 @@
+package awskit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"code.olapie.com/awskit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3_GetRange(t *testing.T) {
+	bucket, _ := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err := bucket.Put(ctx, "video", []byte("0123456789"), nil)
+	require.NoError(t, err)
+
+	content, err := bucket.GetRange(ctx, "video", 2, 5)
+	require.NoError(t, err)
+	require.Equal(t, "2345", string(content))
+
+	r, err := bucket.GetRangeInfo(ctx, "video", 7, -1)
+	require.NoError(t, err)
+	require.Equal(t, "789", string(r.Content))
+	require.Equal(t, int64(7), r.Start)
+	require.Equal(t, int64(9), r.End)
+	require.Equal(t, int64(10), r.Size)
+
+	_, err = bucket.GetRange(ctx, "video", 10, -1)
+	require.True(t, errors.Is(err, awskit.ErrRangeNotSatisfiable))
+
+	_, err = bucket.GetRange(ctx, "video", 5, 2)
+	require.Error(t, err)
+
+	r, err = bucket.GetSuffixRangeInfo(ctx, "video", 4)
+	require.NoError(t, err)
+	require.Equal(t, "6789", string(r.Content))
+	require.Equal(t, int64(6), r.Start)
+	require.Equal(t, int64(10), r.Size)
+}
+
+func TestS3_GetRangeInfo_Empty(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err := bucket.Put(ctx, "empty", nil, nil)
+	require.NoError(t, err)
+
+	r, err := bucket.GetRangeInfo(ctx, "empty", 0, -1)
+	require.NoError(t, err)
+	require.Empty(t, r.Content)
+	require.Zero(t, r.Size)
+	require.Equal(t, 1, fake.Requests["GetObject"])
+
+	_, err = bucket.GetSuffixRangeInfo(ctx, "empty", 10)
+	require.True(t, errors.Is(err, awskit.ErrRangeNotSatisfiable))
+}
@@ -0,0 +1,55 @@
+package awskit
+
+import (
+	"context"
+	"strings"
+
+	"code.olapie.com/sugar/v2/xerror"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// EnsureRetrievable returns true if the object can be read right now. For an archived object, i.e. in GLACIER or DEEP_ARCHIVE,
+// or in an archive tier of INTELLIGENT_TIERING, it initiates a restore which keeps a readable copy for restoreDays
+// and returns false, so callers can ask clients to retry later. Calling it again while the restore is ongoing returns false.
+// restoreDays doesn't apply to INTELLIGENT_TIERING which moves restored objects back to the frequent access tier.
+func (s *S3Bucket) EnsureRetrievable(ctx context.Context, key string, restoreDays int) (bool, error) {
+	head, err := s.GetHeadObject(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	request := new(types.RestoreRequest)
+	switch {
+	case head.StorageClass == types.StorageClassGlacier, head.StorageClass == types.StorageClassDeepArchive:
+		request.Days = int32(restoreDays)
+	case head.ArchiveStatus != "":
+	default:
+		return true, nil
+	}
+
+	// Restore is like ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT" once restored
+	if restore := aws.ToString(head.Restore); restore != "" {
+		if strings.Contains(restore, `ongoing-request="true"`) {
+			return false, nil
+		}
+		if head.ArchiveStatus == "" {
+			return true, nil
+		}
+	}
+
+	_, err = s.client.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket:         aws.String(s.bucket),
+		Key:            aws.String(s.normalizeKey(key)),
+		RestoreRequest: request,
+	}, s.clientOptions()...)
+	if err != nil {
+		if apiErr, ok := xerror.CauseOf[smithy.APIError](err); ok && apiErr.ErrorCode() == "RestoreAlreadyInProgress" {
+			return false, nil
+		}
+		return false, wrapS3Error(ctx, "RestoreObject", key, err)
+	}
+	return false, nil
+}
@@ -0,0 +1,123 @@
+package awskit
+
+import (
+	"math/rand"
+	"strconv"
+	"time"
+
+	"code.olapie.com/sugar/v2/xerror"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// RetryableFunc reports whether a failed S3 operation should be retried
+type RetryableFunc func(err error) bool
+
+// classifiedRetryer overrides the error classification of the client's retryer
+type classifiedRetryer struct {
+	aws.Retryer
+	retryable RetryableFunc
+}
+
+func (r *classifiedRetryer) IsErrorRetryable(err error) bool {
+	return r.retryable(err)
+}
+
+// clientOptions returns per-operation client options derived from the bucket's settings
+func (s *S3Bucket) clientOptions() []func(*s3.Options) {
+	var optFns []func(*s3.Options)
+	if s.retryer != nil {
+		optFns = append(optFns, func(o *s3.Options) {
+			o.Retryer = s.retryer
+		})
+	}
+	if s.RetryableFunc != nil {
+		optFns = append(optFns, func(o *s3.Options) {
+			if o.Retryer == nil {
+				return
+			}
+			o.Retryer = &classifiedRetryer{
+				Retryer:   o.Retryer,
+				retryable: s.RetryableFunc,
+			}
+		})
+	}
+	if s.Observer != nil {
+		optFns = append(optFns, func(o *s3.Options) {
+			// don't append to the client's shared slice
+			o.APIOptions = append(o.APIOptions[:len(o.APIOptions):len(o.APIOptions)], s.addObserver)
+		})
+	}
+	if s.DryRun {
+		optFns = append(optFns, func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions[:len(o.APIOptions):len(o.APIOptions)], s.addDryRun)
+		})
+	}
+	if s.MaxConcurrency > 0 {
+		optFns = append(optFns, func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions[:len(o.APIOptions):len(o.APIOptions)], s.addConcurrencyLimit)
+		})
+	}
+	return optFns
+}
+
+// S3BucketOption configures an S3Bucket created by NewS3Bucket
+type S3BucketOption func(s *S3Bucket)
+
+// WithRetry retries transient failures of all operations up to maxAttempts times in total,
+// i.e. SlowDown, RequestTimeout, InternalError and other 5xx responses, but never NotFound or AccessDenied.
+// Delays grow exponentially from baseDelay with jitter, unless the response has Retry-After which is honored.
+// Sleeping stops when the operation's context is done. It replaces the client's retryer.
+func WithRetry(maxAttempts int, baseDelay time.Duration) S3BucketOption {
+	return func(s *S3Bucket) {
+		s.retryer = retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = maxAttempts
+			o.Backoff = &exponentialBackoff{base: baseDelay}
+			o.Retryables = []retry.IsErrorRetryable{retry.IsErrorRetryableFunc(isTransientS3Error)}
+		})
+	}
+}
+
+// transientS3ErrorCodes are worth retrying as they're caused by load or intermittent failures of S3
+var transientS3ErrorCodes = map[string]bool{
+	"SlowDown":           true,
+	"RequestTimeout":     true,
+	"InternalError":      true,
+	"ServiceUnavailable": true,
+}
+
+func isTransientS3Error(err error) aws.Ternary {
+	if isAccessDenied(err) {
+		return aws.FalseTernary
+	}
+	if apiErr, ok := xerror.CauseOf[smithy.APIError](err); ok {
+		if transientS3ErrorCodes[apiErr.ErrorCode()] {
+			return aws.TrueTernary
+		}
+	}
+	if respErr, ok := xerror.CauseOf[*awshttp.ResponseError](err); ok && respErr.HTTPStatusCode() >= 500 {
+		return aws.TrueTernary
+	}
+	return aws.FalseTernary
+}
+
+type exponentialBackoff struct {
+	base time.Duration
+}
+
+func (b *exponentialBackoff) BackoffDelay(attempt int, err error) (time.Duration, error) {
+	if respErr, ok := xerror.CauseOf[*awshttp.ResponseError](err); ok && respErr.Response != nil {
+		if seconds, err := strconv.Atoi(respErr.Response.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, nil
+		}
+	}
+	// full jitter over [0, base * 2^(attempt-1)]
+	limit := b.base << (attempt - 1)
+	if limit <= 0 {
+		return 0, nil
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1)), nil
+}
@@ -1,13 +1,20 @@
 package awskit_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"code.olapie.com/awskit"
 	"code.olapie.com/sugar/v2/xerror"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
@@ -78,6 +85,78 @@ func TestS3_Put(t *testing.T) {
 	require.True(t, xerror.IsNotExist(err))
 }
 
+func TestS3_PutStream(t *testing.T) {
+	bucket := setupS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	id := uuid.NewString()
+	content := []byte("<!DOCTYPE html><html><body>" + strings.Repeat(uuid.NewString(), 30) + "</body></html>")
+	metadata := map[string]string{"test-key": "test value"}
+	err := bucket.PutStream(ctx, id, bytes.NewReader(content), int64(len(content)), metadata)
+	require.NoError(t, err)
+
+	readContent, err := bucket.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, content, readContent)
+
+	head, err := bucket.GetHeadObject(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, metadata, head.Metadata)
+	require.Equal(t, "text/html; charset=utf-8", *head.ContentType)
+	require.Equal(t, bucket.CacheControl, *head.CacheControl)
+
+	err = bucket.Delete(ctx, id)
+	require.NoError(t, err)
+}
+
+func TestS3_GetStream(t *testing.T) {
+	bucket := setupS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	id := uuid.NewString()
+	content := []byte("content" + uuid.NewString())
+	_, err := bucket.Put(ctx, id, content, nil)
+	require.NoError(t, err)
+
+	body, err := bucket.GetStream(ctx, id)
+	require.NoError(t, err)
+	head := make([]byte, 7)
+	_, err = io.ReadFull(body, head)
+	require.NoError(t, err)
+	require.Equal(t, content[:7], head)
+
+	// closing must release the HTTP body rather than leave it to the garbage collector
+	require.NoError(t, body.Close())
+	_, err = body.Read(head)
+	require.Error(t, err)
+
+	_, err = bucket.GetStream(ctx, uuid.NewString())
+	require.True(t, xerror.IsNotExist(err))
+
+	err = bucket.Delete(ctx, id)
+	require.NoError(t, err)
+}
+
+func TestS3_Copy(t *testing.T) {
+	bucket := setupS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	src := "copy test/" + uuid.NewString() + " +&?.txt"
+	dst := uuid.NewString()
+	content := []byte("content" + uuid.NewString())
+	_, err := bucket.Put(ctx, src, content, nil)
+	require.NoError(t, err)
+
+	err = bucket.Copy(ctx, src, dst)
+	require.NoError(t, err)
+	readContent, err := bucket.Get(ctx, dst)
+	require.NoError(t, err)
+	require.Equal(t, content, readContent)
+
+	err = bucket.BatchDelete(ctx, []string{src, dst})
+	require.NoError(t, err)
+}
+
 func TestS3_BatchDelete(t *testing.T) {
 	r := setupS3Bucket(t)
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
@@ -95,3 +174,271 @@ func TestS3_BatchDelete(t *testing.T) {
 	err := r.BatchDelete(ctx, ids)
 	require.NoError(t, err)
 }
+
+func TestS3_PutIfChanged(t *testing.T) {
+	bucket := setupS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	id := uuid.NewString()
+	content := []byte("content" + uuid.NewString())
+	uploaded, err := bucket.PutIfChanged(ctx, id, content, nil)
+	require.NoError(t, err)
+	require.True(t, uploaded)
+
+	uploaded, err = bucket.PutIfChanged(ctx, id, content, nil)
+	require.NoError(t, err)
+	require.False(t, uploaded)
+
+	uploaded, err = bucket.PutIfChanged(ctx, id, append(content, '!'), nil)
+	require.NoError(t, err)
+	require.True(t, uploaded)
+
+	err = bucket.Delete(ctx, id)
+	require.NoError(t, err)
+}
+
+func TestValidateS3AccessPointARN(t *testing.T) {
+	valid := []string{
+		"arn:aws:s3:us-west-2:123456789012:accesspoint/test",
+		"arn:aws:s3::123456789012:accesspoint/mfzwi23gnjvgw.mrap",
+	}
+	for _, s := range valid {
+		require.NoError(t, awskit.ValidateS3AccessPointARN(s), s)
+	}
+
+	invalid := []string{
+		"bucket",
+		"arn:aws:sqs:us-west-2:123456789012:accesspoint/test",
+		"arn:aws:s3:us-west-2::accesspoint/test",
+		"arn:aws:s3:us-west-2:123456789012:bucket/test",
+		"arn:aws:s3::123456789012:accesspoint/test",
+		"arn:aws:s3:us-west-2:123456789012:accesspoint/",
+	}
+	for _, s := range invalid {
+		require.Error(t, awskit.ValidateS3AccessPointARN(s), s)
+	}
+}
+
+func TestS3_Get_Empty(t *testing.T) {
+	bucket, _ := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err := bucket.Put(ctx, "empty", nil, nil)
+	require.NoError(t, err)
+
+	content, err := bucket.Get(ctx, "empty")
+	require.NoError(t, err)
+	require.NotNil(t, content)
+	require.Empty(t, content)
+
+	content, err = bucket.Get(ctx, "missing")
+	require.Error(t, err)
+	require.True(t, xerror.IsNotExist(err))
+	require.Nil(t, content)
+}
+
+func TestS3_MaxObjectSize(t *testing.T) {
+	bucket, _ := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err := bucket.Put(ctx, "large", []byte("0123456789"), nil)
+	require.NoError(t, err)
+	_, err = bucket.Put(ctx, "counter", []byte("1234567890"), nil)
+	require.NoError(t, err)
+	require.NoError(t, bucket.PutValue(ctx, "value", "0123456789"))
+	require.NoError(t, bucket.PutChunked(ctx, "chunked", bytes.NewReader([]byte("0123456789")), 4))
+	bucket.MaxObjectSize = 8
+
+	_, err = bucket.Get(ctx, "large")
+	require.ErrorIs(t, err, awskit.ErrObjectTooLarge)
+	_, err = bucket.GetRange(ctx, "large", 0, -1)
+	require.ErrorIs(t, err, awskit.ErrObjectTooLarge)
+	content, err := bucket.GetRange(ctx, "large", 2, 5)
+	require.NoError(t, err)
+	require.Equal(t, "2345", string(content))
+	_, err = awskit.GetValue[string](ctx, bucket, "value")
+	require.ErrorIs(t, err, awskit.ErrObjectTooLarge)
+	_, err = bucket.Increment(ctx, "counter", 1)
+	require.ErrorIs(t, err, awskit.ErrObjectTooLarge)
+	_, err = bucket.GetChunked(ctx, "chunked")
+	require.ErrorIs(t, err, awskit.ErrObjectTooLarge)
+}
+
+func TestS3_DeleteWaitTimeout(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err := bucket.Put(ctx, "key", []byte("content"), nil)
+	require.NoError(t, err)
+
+	bucket.DeleteWaitTimeout = time.Nanosecond
+	err = bucket.Delete(ctx, "key")
+	require.ErrorIs(t, err, awskit.ErrDeleteUnconfirmed)
+	err = bucket.BatchDelete(ctx, []string{"key"})
+	require.ErrorIs(t, err, awskit.ErrDeleteUnconfirmed)
+
+	bucket.DeleteWaitTimeout = 0
+	heads := fake.Requests["HeadObject"]
+	require.NoError(t, bucket.Delete(ctx, "key"))
+	require.NoError(t, bucket.BatchDelete(ctx, []string{"key"}))
+	require.Equal(t, heads, fake.Requests["HeadObject"])
+}
+
+func TestS3_BatchDelete_Chunked(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	ids := make([]string, 2500)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("key-%04d", i)
+	}
+	_, err := bucket.Put(ctx, ids[1234], []byte("content"), nil)
+	require.NoError(t, err)
+
+	err = bucket.BatchDelete(ctx, ids)
+	require.NoError(t, err)
+	require.Equal(t, 3, fake.Requests["DeleteObjects"])
+	_, ok := fake.Object(ids[1234])
+	require.False(t, ok)
+}
+
+func TestS3_BatchDeleteResult_NormalizedKeys(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t)
+	bucket.KeyCase = awskit.KeyCaseLower
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	fake.DeleteErrors = map[string]string{"denied": "AccessDenied"}
+
+	result, err := bucket.BatchDeleteResult(ctx, []string{"Foo", "foo", "Denied", "bar", "denied"})
+	require.NoError(t, err)
+	require.Equal(t, 3, fake.Requests["DeleteObjectsKey"])
+	require.Len(t, result.Items, 5)
+	for i, key := range []string{"Foo", "foo", "Denied", "bar", "denied"} {
+		item := result.Items[i]
+		require.Equal(t, key, item.Key)
+		if strings.EqualFold(key, "denied") {
+			var accessErr *awskit.AccessDeniedError
+			require.ErrorAs(t, item.Err, &accessErr)
+			require.Equal(t, key, accessErr.Key)
+			require.Equal(t, fakeRequestID, accessErr.RequestID)
+			require.Equal(t, fakeHostID, accessErr.HostID)
+		} else {
+			require.NoError(t, item.Err)
+		}
+	}
+}
+
+func TestS3_WithRetry(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t, awskit.WithRetry(3, time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	fake.FailNext = 2
+	_, err := bucket.Put(ctx, "key", []byte("content"), nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, fake.Requests["Failed"])
+	require.Equal(t, 1, fake.Requests["PutObject"])
+
+	fake.FailNext = 3
+	_, err = bucket.Get(ctx, "key")
+	require.Error(t, err)
+	require.Equal(t, 5, fake.Requests["Failed"])
+
+	_, err = bucket.Get(ctx, "missing")
+	require.True(t, xerror.IsNotExist(err))
+	require.Equal(t, 1, fake.Requests["GetObject"])
+}
+
+func TestS3_ContentDisposition(t *testing.T) {
+	bucket, _ := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	disposition := `attachment; filename="report.pdf"`
+	_, err := bucket.PutWithOptions(ctx, "3f2b1c", []byte("%PDF-1.4"), awskit.PutOptions{ContentDisposition: disposition})
+	require.NoError(t, err)
+
+	obj, err := bucket.GetWithMetadata(ctx, "3f2b1c")
+	require.NoError(t, err)
+	require.Equal(t, disposition, obj.ContentDisposition)
+}
+
+func TestS3_ServerSideEncryption(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err := bucket.Put(ctx, "plain", []byte("hello"), nil)
+	require.NoError(t, err)
+	require.Empty(t, fake.Header("plain").Get("X-Amz-Server-Side-Encryption"))
+
+	bucket.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+	bucket.KMSKeyID = "alias/test"
+	_, err = bucket.Put(ctx, "encrypted", []byte("hello"), nil)
+	require.NoError(t, err)
+	header := fake.Header("encrypted")
+	require.Equal(t, "aws:kms", header.Get("X-Amz-Server-Side-Encryption"))
+	require.Equal(t, "alias/test", header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+}
+
+func TestS3_PutWithOptions_Tags(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err := bucket.PutWithOptions(ctx, "tagged", []byte("hello"), awskit.PutOptions{
+		Tags: map[string]string{"project": "a&b", "owner": "john doe"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "owner=john%20doe&project=a%26b", fake.Header("tagged").Get("X-Amz-Tagging"))
+
+	tags := make(map[string]string)
+	for i := 0; i < 11; i++ {
+		tags[fmt.Sprint("k", i)] = "v"
+	}
+	_, err = bucket.PutWithOptions(ctx, "too-many-tags", []byte("hello"), awskit.PutOptions{Tags: tags})
+	require.Error(t, err)
+	_, err = bucket.PutWithOptions(ctx, "long-tag", []byte("hello"), awskit.PutOptions{
+		Tags: map[string]string{strings.Repeat("k", 129): "v"},
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, fake.Requests["PutObject"])
+}
+
+func TestS3_DryRun(t *testing.T) {
+	bucket, fake := newFakeS3Bucket(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err := bucket.Put(ctx, "existing", []byte("hello"), nil)
+	require.NoError(t, err)
+
+	bucket.DryRun = true
+	var records []*awskit.AuditRecord
+	bucket.AuditSink = func(ctx context.Context, record *awskit.AuditRecord) {
+		records = append(records, record)
+	}
+	_, err = bucket.Put(ctx, "new", []byte("hello"), nil)
+	require.NoError(t, err)
+	require.NoError(t, bucket.UpdateMetadata(ctx, "existing", map[string]string{"owner": "tom"}))
+	require.NoError(t, bucket.SetACL(ctx, "existing", types.ObjectCannedACLPublicRead))
+	require.NoError(t, bucket.Delete(ctx, "existing"))
+	require.NoError(t, bucket.BatchDelete(ctx, []string{"existing"}))
+	require.Empty(t, records)
+	require.Equal(t, 1, fake.Requests["PutObject"])
+	require.Zero(t, fake.Requests["DeleteObject"])
+	require.Zero(t, fake.Requests["DeleteObjects"])
+
+	_, ok := fake.Object("new")
+	require.False(t, ok)
+	content, err := bucket.Get(ctx, "existing")
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(content))
+}
+
+func TestNewS3BucketE(t *testing.T) {
+	client := s3.NewFromConfig(aws.Config{Region: "us-west-2"})
+	_, err := awskit.NewS3BucketE("arn:aws:s3:us-west-2:123456789012:accesspoint/test", client)
+	require.NoError(t, err)
+	_, err = awskit.NewS3BucketE("arn:aws:s3:us-west-2:123456789012:bucket/test", client)
+	require.Error(t, err)
+	require.Panics(t, func() {
+		awskit.MustNewS3Bucket("arn:aws:s3:us-west-2::accesspoint/test", client)
+	})
+}
@@ -0,0 +1,88 @@
+package awskit
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidUploadTicket = errors.New("invalid upload ticket")
+	ErrUploadTicketExpired = errors.New("upload ticket expired")
+)
+
+// UploadTicket authorizes an upload of a specific shape. It's issued by IssueUploadTicket and checked by
+// VerifyUploadTicket before presigning the upload.
+type UploadTicket struct {
+	Key         string    `json:"key"`
+	MaxSize     int64     `json:"max_size"`
+	ContentType string    `json:"content_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Check returns an error if an upload of contentType and size isn't allowed by the ticket
+func (t *UploadTicket) Check(contentType string, size int64) error {
+	if t.ContentType != "" && !strings.EqualFold(t.ContentType, contentType) {
+		return fmt.Errorf("%w: content type %s is not allowed", ErrInvalidUploadTicket, contentType)
+	}
+	if size < 0 || (t.MaxSize > 0 && size > t.MaxSize) {
+		return fmt.Errorf("%w: size %d exceeds %d", ErrInvalidUploadTicket, size, t.MaxSize)
+	}
+	return nil
+}
+
+// IssueUploadTicket signs ticket with privKey and returns an opaque token,
+// i.e. base64 encoded ticket and base64 encoded ASN.1 signature over its sha256, joined by a dot
+func IssueUploadTicket(privKey *ecdsa.PrivateKey, ticket *UploadTicket) (string, error) {
+	if ticket.Key == "" {
+		return "", fmt.Errorf("missing key")
+	}
+	if ticket.ExpiresAt.IsZero() {
+		return "", fmt.Errorf("missing expiration")
+	}
+	payload, err := json.Marshal(ticket)
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal: %w", err)
+	}
+	hash := sha256.Sum256(payload)
+	sign, err := ecdsa.SignASN1(rand.Reader, privKey, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("ecdsa.SignASN1: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sign), nil
+}
+
+// VerifyUploadTicket verifies token's signature with pubKey and returns the ticket if it hasn't expired
+func VerifyUploadTicket(pubKey *ecdsa.PublicKey, token string) (*UploadTicket, error) {
+	encodedPayload, encodedSign, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidUploadTicket
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidUploadTicket
+	}
+	sign, err := base64.RawURLEncoding.DecodeString(encodedSign)
+	if err != nil {
+		return nil, ErrInvalidUploadTicket
+	}
+	hash := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(pubKey, hash[:], sign) {
+		return nil, ErrInvalidUploadTicket
+	}
+
+	ticket := new(UploadTicket)
+	if err = json.Unmarshal(payload, ticket); err != nil {
+		return nil, ErrInvalidUploadTicket
+	}
+	if time.Now().After(ticket.ExpiresAt) {
+		return nil, fmt.Errorf("%w: %s", ErrUploadTicketExpired, ticket.Key)
+	}
+	return ticket, nil
+}
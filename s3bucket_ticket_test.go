@@ -0,0 +1,75 @@
+package awskit_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"code.olapie.com/awskit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadTicket(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issue := func(expiresAt time.Time) string {
+		token, err := awskit.IssueUploadTicket(privKey, &awskit.UploadTicket{
+			Key:         "avatars/1.png",
+			MaxSize:     1 << 20,
+			ContentType: "image/png",
+			ExpiresAt:   expiresAt,
+		})
+		require.NoError(t, err)
+		return token
+	}
+	valid := issue(time.Now().Add(time.Minute))
+	payload, sign, _ := strings.Cut(valid, ".")
+	tamperedPayload := base64.RawURLEncoding.EncodeToString([]byte(
+		`{"key":"avatars/1.png","max_size":1073741824,"content_type":"image/png","expires_at":"2099-01-01T00:00:00Z"}`))
+	signBytes, err := base64.RawURLEncoding.DecodeString(sign)
+	require.NoError(t, err)
+	signBytes[len(signBytes)-1] ^= 0xff
+
+	tests := []struct {
+		name   string
+		token  string
+		pubKey *ecdsa.PublicKey
+		err    error
+	}{
+		{"valid", valid, &privKey.PublicKey, nil},
+		{"expired", issue(time.Now().Add(-time.Second)), &privKey.PublicKey, awskit.ErrUploadTicketExpired},
+		{"tampered payload", tamperedPayload + "." + sign, &privKey.PublicKey, awskit.ErrInvalidUploadTicket},
+		{"tampered signature", payload + "." + base64.RawURLEncoding.EncodeToString(signBytes), &privKey.PublicKey, awskit.ErrInvalidUploadTicket},
+		{"other key", valid, &otherKey.PublicKey, awskit.ErrInvalidUploadTicket},
+		{"missing signature", payload, &privKey.PublicKey, awskit.ErrInvalidUploadTicket},
+		{"malformed base64", "!!." + sign, &privKey.PublicKey, awskit.ErrInvalidUploadTicket},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket, err := awskit.VerifyUploadTicket(tt.pubKey, tt.token)
+			if tt.err != nil {
+				require.True(t, errors.Is(err, tt.err), err)
+				require.Nil(t, ticket)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "avatars/1.png", ticket.Key)
+			require.NoError(t, ticket.Check("image/png", 1000))
+			require.True(t, errors.Is(ticket.Check("image/svg+xml", 1000), awskit.ErrInvalidUploadTicket))
+			require.True(t, errors.Is(ticket.Check("image/png", 2<<20), awskit.ErrInvalidUploadTicket))
+		})
+	}
+
+	_, err = awskit.IssueUploadTicket(privKey, &awskit.UploadTicket{Key: "a"})
+	require.Error(t, err)
+	_, err = awskit.IssueUploadTicket(privKey, &awskit.UploadTicket{ExpiresAt: time.Now().Add(time.Minute)})
+	require.Error(t, err)
+}
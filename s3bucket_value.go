@@ -0,0 +1,84 @@
+package awskit
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// metadataKeyValueType is the metadata key of the type tag stored by PutValue
+const metadataKeyValueType = "awskit-value-type"
+
+// TypeMismatchError is returned by GetValue if the stored value's type differs from the requested one
+type TypeMismatchError struct {
+	Key      string
+	Expected string
+	Actual   string
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("type mismatch: %s stores %s rather than %s", e.Key, e.Actual, e.Expected)
+}
+
+// PutValue gob encodes v and stores it with v's type tag, which is verified by GetValue
+func (s *S3Bucket) PutValue(ctx context.Context, key string, v any, optFns ...func(input *s3.PutObjectInput)) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return fmt.Errorf("gob.Encode: %w", err)
+	}
+	optFns = append([]func(*s3.PutObjectInput){func(input *s3.PutObjectInput) {
+		input.ContentType = aws.String("application/x-gob")
+	}}, optFns...)
+	_, err := s.Put(ctx, key, buf.Bytes(), map[string]string{
+		metadataKeyValueType: typeTag(reflect.TypeOf(v)),
+	}, optFns...)
+	return err
+}
+
+// GetValue reads a value stored by PutValue. It returns *TypeMismatchError if the stored type isn't T.
+func GetValue[T any](ctx context.Context, s *S3Bucket, key string, optFns ...func(input *s3.GetObjectInput)) (T, error) {
+	var v T
+	output, err := s.getObject(ctx, key, optFns...)
+	if err != nil {
+		return v, err
+	}
+	defer output.Body.Close()
+
+	expected := typeTag(reflect.TypeOf(&v).Elem())
+	if actual := output.Metadata[metadataKeyValueType]; actual != expected {
+		return v, &TypeMismatchError{Key: key, Expected: expected, Actual: actual}
+	}
+
+	content, err := s.readContent(key, output)
+	if err != nil {
+		return v, err
+	}
+	if err = gob.NewDecoder(bytes.NewReader(content)).Decode(&v); err != nil {
+		return v, fmt.Errorf("gob.Decode: %w", err)
+	}
+	return v, nil
+}
+
+// typeTag returns the full name of t, e.g. *example.com/pkg.User
+func typeTag(t reflect.Type) string {
+	if t == nil {
+		return "nil"
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + typeTag(t.Elem())
+	case reflect.Slice:
+		return "[]" + typeTag(t.Elem())
+	case reflect.Map:
+		return "map[" + typeTag(t.Key()) + "]" + typeTag(t.Elem())
+	}
+	if t.PkgPath() != "" {
+		return t.PkgPath() + "." + t.Name()
+	}
+	return t.String()
+}
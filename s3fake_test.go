@@ -0,0 +1,288 @@
+package awskit_test
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"code.olapie.com/awskit"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 is an in-memory S3 server for tests which must not depend on a real bucket,
+// e.g. to count requests or inject failures. It only implements what the tests need.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]*fakeObject
+	uploads map[string]map[int][]byte
+	nextID  int
+
+	// Requests counts requests by operation name, e.g. UploadPart
+	Requests map[string]int
+
+	// FailNext makes the next FailNext requests fail with 503 SlowDown
+	FailNext int
+
+	// FailPart makes UploadPart of the part number fail with a non-retryable error if not zero
+	FailPart int
+
+	// DeleteErrors makes DeleteObjects report keys as failed with the error codes, e.g. AccessDenied
+	DeleteErrors map[string]string
+}
+
+// fakeRequestID and fakeHostID are x-amz-request-id and x-amz-id-2 of all responses
+const (
+	fakeRequestID = "fake-request-id"
+	fakeHostID    = "fake-host-id"
+)
+
+type fakeObject struct {
+	content []byte
+	header  http.Header
+}
+
+func newFakeS3Bucket(t *testing.T, options ...awskit.S3BucketOption) (*awskit.S3Bucket, *fakeS3) {
+	f := &fakeS3{
+		objects:  make(map[string]*fakeObject),
+		uploads:  make(map[string]map[int][]byte),
+		Requests: make(map[string]int),
+	}
+	srv := httptest.NewTLSServer(f)
+	t.Cleanup(srv.Close)
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: aws.AnonymousCredentials{},
+		HTTPClient:  srv.Client(),
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.EndpointResolver = s3.EndpointResolverFromURL(srv.URL)
+		o.UsePathStyle = true
+	})
+	return awskit.NewS3Bucket("test", client, options...), f
+}
+
+func (f *fakeS3) Object(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[key]
+	if !ok {
+		return nil, false
+	}
+	return obj.content, true
+}
+
+// Header returns the stored headers of key's object, i.e. content type, disposition, metadata and encryption
+func (f *fakeS3) Header(key string) http.Header {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[key]
+	if !ok {
+		return nil
+	}
+	return obj.header.Clone()
+}
+
+func (f *fakeS3) PendingUploads() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.uploads)
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/test/")
+	query := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("X-Amz-Request-Id", fakeRequestID)
+	w.Header().Set("X-Amz-Id-2", fakeHostID)
+	if f.FailNext > 0 {
+		f.FailNext--
+		f.Requests["Failed"]++
+		writeError(w, http.StatusServiceUnavailable, "SlowDown")
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.Requests["CreateMultipartUpload"]++
+		f.nextID++
+		id := strconv.Itoa(f.nextID)
+		f.uploads[id] = make(map[int][]byte)
+		writeXML(w, fmt.Sprintf("<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id))
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		f.Requests["UploadPart"]++
+		parts, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		if number == f.FailPart {
+			writeError(w, http.StatusBadRequest, "InvalidArgument")
+			return
+		}
+		parts[number] = body
+		w.Header().Set("ETag", etagOf(body))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		f.Requests["CompleteMultipartUpload"]++
+		parts, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &complete); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var content []byte
+		for _, p := range complete.Parts {
+			if etagOf(parts[p.PartNumber]) != p.ETag {
+				writeError(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			content = append(content, parts[p.PartNumber]...)
+		}
+		delete(f.uploads, query.Get("uploadId"))
+		f.objects[key] = &fakeObject{content: content, header: http.Header{}}
+		writeXML(w, fmt.Sprintf("<CompleteMultipartUploadResult><ETag>%s</ETag></CompleteMultipartUploadResult>", etagOf(content)))
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		f.Requests["AbortMultipartUpload"]++
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && query.Has("delete"):
+		f.Requests["DeleteObjects"]++
+		var del struct {
+			Objects []struct {
+				Key string
+			} `xml:"Object"`
+		}
+		if err := xml.Unmarshal(body, &del); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var result strings.Builder
+		for _, o := range del.Objects {
+			f.Requests["DeleteObjectsKey"]++
+			if code := f.DeleteErrors[o.Key]; code != "" {
+				result.WriteString("<Error><Key>" + o.Key + "</Key><Code>" + code + "</Code><Message>" + code + "</Message></Error>")
+				continue
+			}
+			delete(f.objects, o.Key)
+			result.WriteString("<Deleted><Key>" + o.Key + "</Key></Deleted>")
+		}
+		writeXML(w, "<DeleteResult>"+result.String()+"</DeleteResult>")
+	case r.Method == http.MethodPut:
+		f.Requests["PutObject"]++
+		header := http.Header{}
+		for k, v := range r.Header {
+			if k == "Content-Type" || k == "Content-Disposition" || strings.HasPrefix(k, "X-Amz-Meta-") ||
+				strings.HasPrefix(k, "X-Amz-Server-Side-Encryption") || k == "X-Amz-Tagging" {
+				header[k] = v
+			}
+		}
+		f.objects[key] = &fakeObject{content: body, header: header}
+		w.Header().Set("ETag", etagOf(body))
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		f.Requests[map[string]string{http.MethodGet: "GetObject", http.MethodHead: "HeadObject"}[r.Method]]++
+		obj, ok := f.objects[key]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		for k, v := range obj.header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("ETag", etagOf(obj.content))
+		content := obj.content
+		status := http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, end, ok := parseFakeRange(rng, len(content))
+			if !ok {
+				writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+			content = content[start : end+1]
+			status = http.StatusPartialContent
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(content)
+		}
+	case r.Method == http.MethodDelete:
+		f.Requests["DeleteObject"]++
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// parseFakeRange parses a single range like bytes=0-99, bytes=100- or bytes=-100
+func parseFakeRange(rng string, size int) (start, end int, ok bool) {
+	first, last, ok := strings.Cut(strings.TrimPrefix(rng, "bytes="), "-")
+	if !ok {
+		return 0, 0, false
+	}
+	if first == "" {
+		n, err := strconv.Atoi(last)
+		if err != nil || n <= 0 || size == 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true
+	}
+	start, err := strconv.Atoi(first)
+	if err != nil || start >= size {
+		return 0, 0, false
+	}
+	end = size - 1
+	if last != "" {
+		if end, err = strconv.Atoi(last); err != nil {
+			return 0, 0, false
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, true
+}
+
+func etagOf(content []byte) string {
+	sum := md5.Sum(content)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeXML(w http.ResponseWriter, s string) {
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = io.WriteString(w, s)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
@@ -0,0 +1,228 @@
+package awskit
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	SNSTypeNotification             = "Notification"
+	SNSTypeSubscriptionConfirmation = "SubscriptionConfirmation"
+	SNSTypeUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+)
+
+// ErrInvalidSNSSignature is returned if an SNS message's signature can't be verified
+var ErrInvalidSNSSignature = errors.New("invalid sns signature")
+
+var snsHostRegexp = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// SNSMessage is a message delivered by SNS to an HTTP/HTTPS endpoint
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageId        string `json:"MessageId"`
+	Token            string `json:"Token,omitempty"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject,omitempty"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+	SubscribeURL     string `json:"SubscribeURL,omitempty"`
+	UnsubscribeURL   string `json:"UnsubscribeURL,omitempty"`
+}
+
+// SNSMessageVerifier verifies signatures of SNS messages delivered over HTTP.
+// Signing certificates are downloaded once and cached.
+type SNSMessageVerifier struct {
+	client *http.Client
+	certs  sync.Map // url -> *x509.Certificate
+}
+
+func NewSNSMessageVerifier(client *http.Client) *SNSMessageVerifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &SNSMessageVerifier{
+		client: client,
+	}
+}
+
+// Handle parses and verifies body. Subscription confirmations are confirmed automatically.
+// It returns the verified message, whose Type tells callers whether it's a notification to process.
+func (v *SNSMessageVerifier) Handle(ctx context.Context, body []byte) (*SNSMessage, error) {
+	msg := new(SNSMessage)
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if err := v.Verify(ctx, msg); err != nil {
+		return nil, err
+	}
+	if msg.Type == SNSTypeSubscriptionConfirmation {
+		if err := v.confirmSubscription(ctx, msg); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// Verify checks msg's signature against its signing certificate, which must be hosted by SNS
+func (v *SNSMessageVerifier) Verify(ctx context.Context, msg *SNSMessage) error {
+	var hash crypto.Hash
+	switch msg.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("%w: unsupported signature version %q", ErrInvalidSNSSignature, msg.SignatureVersion)
+	}
+
+	sign, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSNSSignature, err)
+	}
+
+	stringToSign, err := snsStringToSign(msg)
+	if err != nil {
+		return err
+	}
+
+	cert, err := v.getCert(ctx, msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	pubKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: signing cert doesn't have an rsa key", ErrInvalidSNSSignature)
+	}
+
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum([]byte(stringToSign))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(stringToSign))
+		digest = sum[:]
+	}
+	if err = rsa.VerifyPKCS1v15(pubKey, hash, digest, sign); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSNSSignature, err)
+	}
+	return nil
+}
+
+func (v *SNSMessageVerifier) getCert(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	if cert, ok := v.certs.Load(certURL); ok {
+		return cert.(*x509.Certificate), nil
+	}
+
+	u, err := validateSNSURL(certURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(u.Path, ".pem") {
+		return nil, fmt.Errorf("%w: invalid signing cert url %s", ErrInvalidSNSSignature, certURL)
+	}
+
+	data, err := v.get(ctx, certURL)
+	if err != nil {
+		return nil, fmt.Errorf("download signing cert: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: invalid signing cert", ErrInvalidSNSSignature)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParseCertificate: %w", err)
+	}
+	v.certs.Store(certURL, cert)
+	return cert, nil
+}
+
+func (v *SNSMessageVerifier) confirmSubscription(ctx context.Context, msg *SNSMessage) error {
+	if _, err := validateSNSURL(msg.SubscribeURL); err != nil {
+		return err
+	}
+	if _, err := v.get(ctx, msg.SubscribeURL); err != nil {
+		return fmt.Errorf("confirm subscription: %w", err)
+	}
+	return nil
+}
+
+func (v *SNSMessageVerifier) get(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// validateSNSURL checks u is an https url hosted by SNS, so that requests are never sent to spoofed hosts
+func validateSNSURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "https" || !snsHostRegexp.MatchString(u.Hostname()) {
+		return nil, fmt.Errorf("%w: untrusted url %s", ErrInvalidSNSSignature, s)
+	}
+	return u, nil
+}
+
+// snsStringToSign builds the canonical string as documented in
+// https://docs.aws.amazon.com/sns/latest/dg/sns-verify-signature-of-message.html
+func snsStringToSign(msg *SNSMessage) (string, error) {
+	var keys []string
+	switch msg.Type {
+	case SNSTypeNotification:
+		keys = []string{"Message", "MessageId", "Subject", "Timestamp", "TopicArn", "Type"}
+	case SNSTypeSubscriptionConfirmation, SNSTypeUnsubscribeConfirmation:
+		keys = []string{"Message", "MessageId", "SubscribeURL", "Timestamp", "Token", "TopicArn", "Type"}
+	default:
+		return "", fmt.Errorf("%w: unknown message type %q", ErrInvalidSNSSignature, msg.Type)
+	}
+
+	values := map[string]string{
+		"Message":      msg.Message,
+		"MessageId":    msg.MessageId,
+		"Subject":      msg.Subject,
+		"SubscribeURL": msg.SubscribeURL,
+		"Timestamp":    msg.Timestamp,
+		"Token":        msg.Token,
+		"TopicArn":     msg.TopicArn,
+		"Type":         msg.Type,
+	}
+	var b strings.Builder
+	for _, k := range keys {
+		// Subject is only signed if present
+		if k == "Subject" && msg.Subject == "" {
+			continue
+		}
+		b.WriteString(k)
+		b.WriteByte('\n')
+		b.WriteString(values[k])
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,267 @@
+package awskit_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"code.olapie.com/awskit"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSNSCertURL      = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+	testSNSSubscribeURL = "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&Token=token"
+)
+
+// snsServer pretends to be SNS for every host, serving the signing cert and subscription confirmations
+type snsServer struct {
+	key      *rsa.PrivateKey
+	client   *http.Client
+	certs    int32
+	confirms int32
+}
+
+func newSNSServer(t *testing.T) *snsServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	s := &snsServer{key: key}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, ".pem"):
+			atomic.AddInt32(&s.certs, 1)
+			_, _ = w.Write(certPEM)
+		case r.URL.Query().Get("Action") == "ConfirmSubscription":
+			atomic.AddInt32(&s.confirms, 1)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	// the test server's cert isn't issued for SNS hosts
+	transport.TLSClientConfig.InsecureSkipVerify = true
+	s.client = &http.Client{Transport: transport}
+	return s
+}
+
+// sign signs msg like SNS does. signEmptySubject signs Subject even if it's empty, which SNS never does.
+func (s *snsServer) sign(t *testing.T, msg *awskit.SNSMessage, signEmptySubject bool) {
+	keys := []string{"Message", "MessageId", "Subject", "Timestamp", "TopicArn", "Type"}
+	if msg.Type != awskit.SNSTypeNotification {
+		keys = []string{"Message", "MessageId", "SubscribeURL", "Timestamp", "Token", "TopicArn", "Type"}
+	}
+	values := map[string]string{
+		"Message":      msg.Message,
+		"MessageId":    msg.MessageId,
+		"Subject":      msg.Subject,
+		"SubscribeURL": msg.SubscribeURL,
+		"Timestamp":    msg.Timestamp,
+		"Token":        msg.Token,
+		"TopicArn":     msg.TopicArn,
+		"Type":         msg.Type,
+	}
+	var b strings.Builder
+	for _, k := range keys {
+		if k == "Subject" && msg.Subject == "" && !signEmptySubject {
+			continue
+		}
+		b.WriteString(k + "\n" + values[k] + "\n")
+	}
+
+	var sign []byte
+	var err error
+	if msg.SignatureVersion == "1" {
+		sum := sha1.Sum([]byte(b.String()))
+		sign, err = rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA1, sum[:])
+	} else {
+		sum := sha256.Sum256([]byte(b.String()))
+		sign, err = rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, sum[:])
+	}
+	require.NoError(t, err)
+	msg.Signature = base64.StdEncoding.EncodeToString(sign)
+}
+
+func newTestSNSNotification(version string) *awskit.SNSMessage {
+	return &awskit.SNSMessage{
+		Type:             awskit.SNSTypeNotification,
+		MessageId:        "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+		TopicArn:         "arn:aws:sns:us-east-1:123456789012:test",
+		Subject:          "greeting",
+		Message:          "hello",
+		Timestamp:        "2012-05-02T00:54:06.655Z",
+		SignatureVersion: version,
+		SigningCertURL:   testSNSCertURL,
+	}
+}
+
+func TestSNSMessageVerifier_Verify(t *testing.T) {
+	server := newSNSServer(t)
+
+	tests := []struct {
+		name  string
+		build func(t *testing.T) *awskit.SNSMessage
+		valid bool
+	}{
+		{"signature version 1", func(t *testing.T) *awskit.SNSMessage {
+			msg := newTestSNSNotification("1")
+			server.sign(t, msg, false)
+			return msg
+		}, true},
+		{"signature version 2", func(t *testing.T) *awskit.SNSMessage {
+			msg := newTestSNSNotification("2")
+			server.sign(t, msg, false)
+			return msg
+		}, true},
+		{"version 1 signature claimed as version 2", func(t *testing.T) *awskit.SNSMessage {
+			msg := newTestSNSNotification("1")
+			server.sign(t, msg, false)
+			msg.SignatureVersion = "2"
+			return msg
+		}, false},
+		{"unsupported signature version", func(t *testing.T) *awskit.SNSMessage {
+			msg := newTestSNSNotification("3")
+			server.sign(t, msg, false)
+			return msg
+		}, false},
+		{"missing subject is not signed", func(t *testing.T) *awskit.SNSMessage {
+			msg := newTestSNSNotification("2")
+			msg.Subject = ""
+			server.sign(t, msg, false)
+			return msg
+		}, true},
+		{"missing subject signed as empty", func(t *testing.T) *awskit.SNSMessage {
+			msg := newTestSNSNotification("2")
+			msg.Subject = ""
+			server.sign(t, msg, true)
+			return msg
+		}, false},
+		{"tampered message", func(t *testing.T) *awskit.SNSMessage {
+			msg := newTestSNSNotification("2")
+			server.sign(t, msg, false)
+			msg.Message = "goodbye"
+			return msg
+		}, false},
+		{"tampered signature", func(t *testing.T) *awskit.SNSMessage {
+			msg := newTestSNSNotification("2")
+			server.sign(t, msg, false)
+			sign, _ := base64.StdEncoding.DecodeString(msg.Signature)
+			sign[0] ^= 0xff
+			msg.Signature = base64.StdEncoding.EncodeToString(sign)
+			return msg
+		}, false},
+		{"subscription confirmation", func(t *testing.T) *awskit.SNSMessage {
+			msg := newTestSNSNotification("2")
+			msg.Type = awskit.SNSTypeSubscriptionConfirmation
+			msg.Subject = ""
+			msg.Token = "token"
+			msg.SubscribeURL = testSNSSubscribeURL
+			server.sign(t, msg, false)
+			return msg
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := awskit.NewSNSMessageVerifier(server.client)
+			err := verifier.Verify(context.Background(), tt.build(t))
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.True(t, errors.Is(err, awskit.ErrInvalidSNSSignature), err)
+			}
+		})
+	}
+}
+
+func TestSNSMessageVerifier_UntrustedURL(t *testing.T) {
+	server := newSNSServer(t)
+
+	certURLs := []string{
+		"http://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem",
+		"https://sns.us-east-1.amazonaws.com.evil.com/SimpleNotificationService-test.pem",
+		"https://evil.com/SimpleNotificationService-test.pem",
+		"https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.txt",
+	}
+	for _, u := range certURLs {
+		msg := newTestSNSNotification("2")
+		msg.SigningCertURL = u
+		server.sign(t, msg, false)
+		err := awskit.NewSNSMessageVerifier(server.client).Verify(context.Background(), msg)
+		require.True(t, errors.Is(err, awskit.ErrInvalidSNSSignature), u)
+	}
+	require.Zero(t, atomic.LoadInt32(&server.certs))
+
+	subscribeURLs := []string{
+		"http://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription",
+		"https://evil.com/?Action=ConfirmSubscription",
+		"https://sns.us-east-1.amazonaws.com.evil.com/?Action=ConfirmSubscription",
+	}
+	for _, u := range subscribeURLs {
+		msg := newTestSNSNotification("2")
+		msg.Type = awskit.SNSTypeSubscriptionConfirmation
+		msg.Subject = ""
+		msg.Token = "token"
+		msg.SubscribeURL = u
+		server.sign(t, msg, false)
+		body, err := json.Marshal(msg)
+		require.NoError(t, err)
+		_, err = awskit.NewSNSMessageVerifier(server.client).Handle(context.Background(), body)
+		require.True(t, errors.Is(err, awskit.ErrInvalidSNSSignature), u)
+	}
+	require.Zero(t, atomic.LoadInt32(&server.confirms))
+}
+
+func TestSNSMessageVerifier_Handle(t *testing.T) {
+	server := newSNSServer(t)
+	msg := newTestSNSNotification("2")
+	msg.Type = awskit.SNSTypeSubscriptionConfirmation
+	msg.Subject = ""
+	msg.Token = "token"
+	msg.SubscribeURL = testSNSSubscribeURL
+	server.sign(t, msg, false)
+	body, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	verifier := awskit.NewSNSMessageVerifier(server.client)
+	handled, err := verifier.Handle(context.Background(), body)
+	require.NoError(t, err)
+	require.Equal(t, awskit.SNSTypeSubscriptionConfirmation, handled.Type)
+	require.Equal(t, int32(1), atomic.LoadInt32(&server.confirms))
+
+	// the signing cert is cached
+	notification := newTestSNSNotification("2")
+	server.sign(t, notification, false)
+	require.NoError(t, verifier.Verify(context.Background(), notification))
+	require.Equal(t, int32(1), atomic.LoadInt32(&server.certs))
+}